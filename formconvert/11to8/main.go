@@ -6,184 +6,68 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
-)
-
-type RoleType string
 
-const (
-	CenterBack        RoleType = "CD"
-	FullBack          RoleType = "FB"
-	CentralMidfielder RoleType = "CM"
-	WideMidfielder    RoleType = "WM"
-	Striker           RoleType = "ST"
+	"fusionform/formation"
 )
 
-type Role struct {
-	RoleType RoleType
-	Name     string
-}
-
-func convert11to8Relational(formation11 []Role) []Role {
-	roles8 := []Role{}
-	remainingRoles11 := formation11
-
-	cdRoles := filterRoles(remainingRoles11, []RoleType{CenterBack, FullBack})
-	selectedCDs := selectRoles(cdRoles, 2, []RoleType{CenterBack})
-	roles8 = append(roles8, selectedCDs...)
-	remainingRoles11 = removeRoles(remainingRoles11, selectedCDs)
-
-	cmRoles := filterRoles(remainingRoles11, []RoleType{CentralMidfielder, WideMidfielder, FullBack})
-	selectedCMs := selectRoles(cmRoles, 3, []RoleType{CentralMidfielder, WideMidfielder})
-	roles8 = append(roles8, selectedCMs...)
-	remainingRoles11 = removeRoles(remainingRoles11, selectedCMs)
-
-	stRoles := filterRoles(remainingRoles11, []RoleType{Striker, WideMidfielder, FullBack})
-	selectedSTs := selectRoles(stRoles, 2, []RoleType{Striker, WideMidfielder})
-	roles8 = append(roles8, selectedSTs...)
-	remainingRoles11 = removeRoles(remainingRoles11, selectedSTs)
-
-	if len(roles8) < 7 && len(remainingRoles11) > 0 {
-		flexibleRoles := filterRoles(remainingRoles11, []RoleType{WideMidfielder, FullBack, Striker, CentralMidfielder, CenterBack})
-		selectedFlex := selectRoles(flexibleRoles, 1, []RoleType{WideMidfielder, FullBack, CentralMidfielder, Striker, CenterBack})
-		if len(selectedFlex) > 0 {
-			// roles8 = append(roles8, Role{RoleType: Flexible, Name: "Flex_Outfield_1"})
-		}
-	}
-
-	// roles8 = append(roles8, Role{RoleType: Goalkeeper, Name: "GK_1"})
-	return roles8
-}
-
-func filterRoles(roles []Role, roleTypes []RoleType) []Role {
-	filtered := []Role{}
-	for _, role := range roles {
-		for _, rt := range roleTypes {
-			if role.RoleType == rt {
-				filtered = append(filtered, role)
-				break
-			}
-		}
+func main() {
+	strategy := flag.String("strategy", "greedy", "conversion strategy: "+strings.Join(formation.StrategyNames(formation.Strategies11to8), ", "))
+	alternatives := flag.Int("alternatives", 0, "also list the top n alternative shapes, scored")
+	explain := flag.Bool("explain", false, "print which rule placed each role and why")
+	priorityList := flag.String("priority", "def,mid,att", "line order for settling ties when scaling proportionally")
+	styleName := flag.String("style", "balanced", "tactical preset: "+strings.Join(formation.StyleNames(), ", "))
+	constraintSpec := flag.String("constraints", "", `constraints, e.g. "pin=CD_1,ST_2; lines=3-2-1; forbid=Flex; min=ST:1"`)
+	compatPath := flag.String("compat", "", "role compatibility CSV (from,to,score) laid over the built-in matrix")
+	compare := flag.Bool("compare", false, "score every strategy on the input and list them best first")
+	lintPath := flag.String("lint", "", "lint rules CSV (rule,format,value,severity) laid over the built-in rules; \"off\" to skip linting")
+	mirror := flag.Bool("mirror", false, "flip the input formation left to right before converting")
+	templatesPath := flag.String("templates", "", "extra formation templates CSV (name,aliases,lines); template names such as \"diamond\" are accepted as input")
+	flag.Parse()
+	convert, ok := formation.Strategies11to8[*strategy]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown strategy %q\n", *strategy)
+		os.Exit(1)
 	}
-	return filtered
-}
-
-func selectRoles(roles []Role, n int, preferredRoleTypes []RoleType) []Role {
-	selected := []Role{}
-	for _, rt := range preferredRoleTypes {
-		for _, role := range roles {
-			if role.RoleType == rt && len(selected) < n {
-				selected = append(selected, role)
-			}
+	compat, err := formation.LoadCompatibility(*compatPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: loading compatibility:", err)
+		os.Exit(1)
+	}
+	if err := formation.LoadTemplates(*templatesPath); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: loading templates:", err)
+		os.Exit(1)
+	}
+	var lintConfig formation.LintConfig
+	if *lintPath != "off" {
+		lintConfig, err = formation.LoadLintConfig(*lintPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: loading lint rules:", err)
+			os.Exit(1)
 		}
 	}
-	for _, role := range roles {
-		alreadySelected := false
-		for _, selRole := range selected {
-			if role == selRole {
-				alreadySelected = true
-				break
-			}
-		}
-		if !alreadySelected && len(selected) < n {
-			selected = append(selected, role)
-		}
+	priority, err := formation.ParsePriority(*priorityList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -priority:", err)
+		os.Exit(1)
 	}
-	return selected
-}
-
-func removeRoles(allRoles []Role, rolesToRemove []Role) []Role {
-	remaining := []Role{}
-	for _, role := range allRoles {
-		isRemoved := false
-		for _, roleToRemove := range rolesToRemove {
-			if role == roleToRemove {
-				isRemoved = true
-				break
-			}
+	style, err := formation.StyleByName(*styleName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -style:", err)
+		os.Exit(1)
+	}
+	var constraints *formation.Constraints
+	if *constraintSpec != "" {
+		if *strategy != "greedy" {
+			fmt.Fprintf(os.Stderr, "Error: -constraints places players by its own optimal assignment and cannot be combined with -strategy %s\n", *strategy)
+			os.Exit(1)
 		}
-		if !isRemoved {
-			remaining = append(remaining, role)
+		constraints, err = formation.ParseConstraints(*constraintSpec)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -constraints:", err)
+			os.Exit(1)
 		}
 	}
-	return remaining
-}
-
-func parseNumericalFormationInput11(input string) ([]Role, error) {
-	if len(input) != 3 {
-		return nil, fmt.Errorf("input must be a 3-digit number string (e.g., 442)")
-	}
-
-	defenders, err := strconv.Atoi(string(input[0]))
-	if err != nil || defenders < 0 {
-		return nil, fmt.Errorf("invalid number of defenders")
-	}
-	midfielders, err := strconv.Atoi(string(input[1]))
-	if err != nil || midfielders < 0 {
-		return nil, fmt.Errorf("invalid number of midfielders")
-	}
-	attackers, err := strconv.Atoi(string(input[2]))
-	if err != nil || attackers < 0 {
-		return nil, fmt.Errorf("invalid number of attackers")
-	}
-
-	totalOutfield := defenders + midfielders + attackers
-	if totalOutfield != 10 {
-		return nil, fmt.Errorf("total outfield players must be 10 for 11-a-side, got %d", totalOutfield)
-	}
-
-	formation := make([]Role, 0, totalOutfield)
-
-	numCD := defenders
-	numFB := 0
-	if defenders == 4 {
-		numCD = 2
-		numFB = 2
-	} else if defenders == 3 {
-		numCD = 3
-		numFB = 0
-	} else if defenders == 5 {
-		numCD = 3
-		numFB = 2
-	}
-
-	for i := 0; i < numCD; i++ {
-		formation = append(formation, Role{RoleType: CenterBack, Name: fmt.Sprintf("CD_%d", i+1)})
-	}
-	for i := 0; i < numFB; i++ {
-		formation = append(formation, Role{RoleType: FullBack, Name: fmt.Sprintf("FB_%d", i+1)})
-	}
-
-	numCM := midfielders
-	numWM := 0
-	if midfielders == 4 {
-		numCM = 2
-		numWM = 2
-	} else if midfielders == 5 {
-		numCM = 3
-		numWM = 2
-	} else if midfielders == 3 {
-		numCM = 3
-		numWM = 0
-	}
-
-	for i := 0; i < numCM; i++ {
-		formation = append(formation, Role{RoleType: CentralMidfielder, Name: fmt.Sprintf("CM_%d", i+1)})
-	}
-	for i := 0; i < numWM; i++ {
-		formation = append(formation, Role{RoleType: WideMidfielder, Name: fmt.Sprintf("WM_%d", i+1)})
-	}
-
-	for i := 0; i < attackers; i++ {
-		formation = append(formation, Role{RoleType: Striker, Name: fmt.Sprintf("ST_%d", i+1)})
-	}
-
-	return formation, nil
-}
-
-func main() {
-	flag.Parse()
 
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Println(`
@@ -216,33 +100,109 @@ func main() {
 					FusionForm(11to8) V0.0.1D By Gordon.H
 					© Deepfield 2025
 	`)
-	fmt.Println("Enter 11-a-side formation in numbers (e.g., 442, 343, 433):")
+	fmt.Println("Enter 11-a-side formation: numbers (e.g., 442, 343, 433), a template (e.g., diamond) or a position list (e.g., FB,CD,CD,FB,WM,CM,CM,WM,ST,ST):")
 	inputFormation11NumStr, _ := reader.ReadString('\n')
 	inputFormation11NumStr = strings.TrimSpace(inputFormation11NumStr)
 
-	formation11, err := parseNumericalFormationInput11(inputFormation11NumStr)
+	formation11, err := formation.ParseNumericalFormationInput11(inputFormation11NumStr)
 	if err != nil {
 		fmt.Println("Error parsing input:", err)
 		return
 	}
+	if *mirror {
+		formation11 = formation.Mirror(formation11)
+	}
+	if notes := formation.Asymmetry(formation11, formation.Pitch11); len(notes) > 0 {
+		fmt.Println("Asymmetric input:")
+		for _, note := range notes {
+			fmt.Println("  " + note)
+		}
+	}
 
-	formation8 := convert11to8Relational(formation11)
+	opts := formation.Options{Compat: compat, Priority: priority, Style: style, Constraints: constraints}
+	if *explain {
+		opts.Trace = &formation.Trace{}
+	}
+	var formation8 []formation.Role
+	if constraints != nil {
+		formation8, err = formation.ConvertConstrained(formation11, 7, opts)
+		if err != nil {
+			fmt.Println("Error applying constraints:", err)
+			return
+		}
+	} else {
+		formation8, err = convert(formation11, opts)
+		if err != nil {
+			fmt.Println("Error converting:", err)
+			return
+		}
+	}
 
 	fmt.Println("\n11-a-side Formation Input:")
 	for _, role := range formation11 {
 		fmt.Printf("%s (%s) ", role.Name, role.RoleType)
 	}
-	fmt.Println("\n\n8-a-side Formation (Relational Conversion):")
+	labelKey := *strategy
+	if constraints != nil {
+		labelKey = "constrained"
+	}
+	label, ok := formation.StrategyLabels[labelKey]
+	if !ok {
+		label = "Relational Conversion"
+	}
+	fmt.Printf("\n\n8-a-side Formation (%s):\n", label)
 	for _, role := range formation8 {
 		fmt.Printf("%s (%s) ", role.Name, role.RoleType)
 	}
 	fmt.Println()
 
-	counts := make(map[RoleType]int)
-	for _, role := range formation8 {
-		counts[role.RoleType]++
-	}
+	counts := formation.Counts(formation8)
 	fmt.Println("\n8-a-side Formation Summary:")
-	fmt.Printf("%d CD, %d CM, %d WM, %d ST\n", counts[CenterBack], counts[CentralMidfielder], counts[WideMidfielder], counts[Striker])
-	fmt.Printf("Formation Count (approx. Outfield): %d-%d-%d\n", counts[CenterBack], counts[CentralMidfielder]+counts[WideMidfielder], counts[Striker])
+	fmt.Printf("%d CD, %d CM, %d WM, %d ST\n", counts[formation.CenterBack]+counts[formation.FullBack], counts[formation.CentralMidfielder], counts[formation.WideMidfielder], counts[formation.Striker])
+	fmt.Printf("Formation Count (approx. Outfield): %d-%d-%d\n", counts[formation.CenterBack]+counts[formation.FullBack], counts[formation.CentralMidfielder]+counts[formation.WideMidfielder], counts[formation.Striker])
+
+	if labelKey == "partnership" {
+		split := formation.Partnerships(formation11).Split(formation8)
+		fmt.Printf("\nPartnerships broken (%d):\n", len(split))
+		for _, p := range split {
+			fmt.Println(" ", p)
+		}
+	}
+
+	fmt.Println("\nConversion quality:")
+	fmt.Print(formation.Assess(formation11, formation8, 7).Report())
+	if *compare {
+		fmt.Println("\nStrategy comparison (best first):")
+		for i, c := range formation.Compare(formation11, formation.Strategies11to8, 7, opts) {
+			fmt.Printf("%d. %-13s %s\n", i+1, c.Strategy, c.Quality)
+		}
+	}
+
+	if findings := lintConfig.Lint(formation8, 8); len(findings) > 0 {
+		fmt.Printf("\nLint (%d):\n", len(findings))
+		for _, f := range findings {
+			fmt.Println(" ", f)
+		}
+	}
+
+	if opts.Trace != nil {
+		fmt.Println("\nConversion trace:")
+		fmt.Print(opts.Trace)
+	}
+
+	if *alternatives > 0 {
+		candidates, err := formation.Alternatives(formation11, 7, *alternatives, opts)
+		if err != nil {
+			fmt.Println("Error listing alternatives:", err)
+			return
+		}
+		fmt.Printf("\nAlternatives (top %d):\n", len(candidates))
+		for i, c := range candidates {
+			fmt.Printf("%d. %s  score %.2f (fit %.2f, balance %.2f): %s\n   ", i+1, c.Shape, c.Score, c.Fit, c.Balance, c.Reason)
+			for _, role := range c.Roles {
+				fmt.Printf("%s (%s) ", role.Name, role.RoleType)
+			}
+			fmt.Println()
+		}
+	}
 }
@@ -1,3 +1,7 @@
 module debug
 
 go 1.24.0
+
+require fusionform v0.0.0
+
+replace fusionform => ../fusionform
@@ -1,3 +1,7 @@
 module 8to11
 
 go 1.24.0
+
+require fusionform v0.0.0
+
+replace fusionform => ../fusionform
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"fusionform/dataset"
+	"fusionform/formation"
+	"fusionform/formations"
+	"fusionform/results"
+	"fusionform/teams"
+)
+
+// dataFlags are the dataset locations shared by every command.
+type dataFlags struct {
+	formationsDir string
+	resultsPath   string
+	aliasesPath   string
+	families      bool
+}
+
+func addDataFlags(fs *flag.FlagSet) *dataFlags {
+	d := &dataFlags{}
+	fs.StringVar(&d.formationsDir, "formations", formations.DefaultDir, "directory of formations_*.csv files")
+	fs.StringVar(&d.resultsPath, "results", results.DefaultPath, "match results parquet file")
+	fs.StringVar(&d.aliasesPath, "aliases", "", "extra team aliases CSV (team,alias) on top of the built-in ones")
+	return d
+}
+
+// addFamilies registers -families, for the commands whose load honours it.
+func (d *dataFlags) addFamilies(fs *flag.FlagSet) {
+	fs.BoolVar(&d.families, "families", false, "replace each formation by its family's canonical formation (see 'fusiondata families')")
+}
+
+func (d *dataFlags) registry() (*teams.Registry, error) {
+	if d.aliasesPath == "" {
+		return teams.Default(), nil
+	}
+	names, err := teams.Load(d.aliasesPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading aliases: %w", err)
+	}
+	return names, nil
+}
+
+// load reads both datasets and joins them.
+func (d *dataFlags) load() (dataset.JoinResult, error) {
+	names, err := d.registry()
+	if err != nil {
+		return dataset.JoinResult{}, err
+	}
+	matches, err := results.Load(d.resultsPath, names)
+	if err != nil {
+		return dataset.JoinResult{}, fmt.Errorf("loading results: %w", err)
+	}
+	lineups, err := formations.LoadDir(d.formationsDir, names)
+	if err != nil {
+		return dataset.JoinResult{}, fmt.Errorf("loading formations: %w", err)
+	}
+	if d.families {
+		for i := range lineups {
+			lineups[i].Formation = formation.Canonical(lineups[i].Formation)
+		}
+	}
+	return dataset.Join(matches, lineups, names), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"fusionform/recommender"
+)
+
+func runEvaluate(args []string) error {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	data := addDataFlags(fs)
+	data.addFamilies(fs)
+	model := addModelFlags(fs)
+	k := fs.Int("folds", 5, "number of cross-validation folds")
+	bySeason := fs.Bool("season-holdout", false, "hold out one season at a time instead of k-fold")
+	perClass := fs.Bool("per-formation", true, "print metrics per formation class")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata evaluate [flags]")
+		fmt.Fprintln(fs.Output(), "Retrains the Go recommender on each fold and scores it against the baselines.")
+		fmt.Fprintln(fs.Output(), "It does not load or score formation_predictor.onnx, the model trained by train.py.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := model.config()
+	if err != nil {
+		return err
+	}
+	joined, err := data.load()
+	if err != nil {
+		return err
+	}
+	samples := recommender.Samples(joined.Fixtures)
+	cfg.Classes = recommender.Classes(samples) // every fold shares the vocabulary
+
+	var folds []recommender.Fold
+	if *bySeason {
+		folds, err = recommender.SeasonHoldout(samples)
+	} else {
+		folds, err = recommender.KFold(samples, *k, cfg.Seed)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Evaluating on %d samples, %d formations, %d folds\n\n", len(samples), len(cfg.Classes), len(folds))
+
+	reports, err := recommender.Evaluate(folds, []recommender.Fitter{
+		recommender.ModelFitter(cfg),
+		recommender.MostFrequentFitter,
+		recommender.MatchupFitter,
+		recommender.GoalFitter,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-14s %8s %9s %8s %7s\n", "Predictor", "Samples", "Accuracy", "LogLoss", "ECE")
+	for _, r := range reports {
+		m := r.Overall
+		fmt.Printf("%-14s %8d %9.3f %8.4f %7.4f\n", r.Name, m.N, m.Accuracy(), m.MeanLogLoss(), m.ECE())
+	}
+
+	if !*perClass {
+		return nil
+	}
+	for _, r := range reports {
+		fmt.Printf("\nPer formation (%s):\n", r.Name)
+		fmt.Printf("%-10s %5s %9s %8s %6s  %-17s  %s\n", "Formation", "N", "Accuracy", "LogLoss", "CalGap", "Predicted W/D/L", "Observed W/D/L")
+		for _, f := range r.Formations() {
+			m := r.ByFormation[f]
+			fmt.Printf("%-10s %5d %9.3f %8.4f %6.3f  %-17s  %s\n", f, m.N, m.Accuracy(), m.MeanLogLoss(), m.CalibrationGap(),
+				formatWDL(m.MeanPredicted()), formatWDL(m.Observed()))
+		}
+	}
+	return nil
+}
+
+func formatWDL(p recommender.Probabilities) string {
+	return fmt.Sprintf("%.2f/%.2f/%.2f", p[recommender.Win], p[recommender.Draw], p[recommender.Loss])
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"fusionform/formation"
+	"fusionform/formations"
+)
+
+func runFamilies(args []string) error {
+	fs := flag.NewFlagSet("families", flag.ExitOnError)
+	data := addDataFlags(fs)
+	k := fs.Int("k", 0, "number of clusters (default: merge until clusters are -max-distance apart)")
+	maxDistance := fs.Float64("max-distance", 0.05, "largest average formation distance merged into one cluster")
+	fs.Parse(args)
+
+	names, err := data.registry()
+	if err != nil {
+		return err
+	}
+	lineups, err := formations.LoadDir(data.formationsDir, names)
+	if err != nil {
+		return fmt.Errorf("loading formations: %w", err)
+	}
+	seen := map[string]int{}
+	vocabulary := []string{}
+	for _, l := range lineups {
+		if seen[l.Formation] == 0 {
+			vocabulary = append(vocabulary, l.Formation)
+		}
+		seen[l.Formation]++
+	}
+
+	type family struct {
+		formation.Family
+		members []string
+		lineups int
+	}
+	families := []*family{}
+	byName := map[string]*family{}
+	unparsed := []string{}
+	for _, v := range vocabulary {
+		fam, err := formation.FamilyOf(v)
+		if err != nil {
+			unparsed = append(unparsed, v)
+			continue
+		}
+		f := byName[fam.Name]
+		if f == nil {
+			f = &family{Family: fam}
+			byName[fam.Name] = f
+			families = append(families, f)
+		}
+		f.members = append(f.members, v)
+		f.lineups += seen[v]
+	}
+
+	fmt.Printf("Lineups: %d, formations: %d\n", len(lineups), len(vocabulary))
+	fmt.Println("\nFamilies (canonical formation, lineups, members):")
+	for _, f := range families {
+		fmt.Printf("  %-24s %-8s %5d  %5.1f%%  %s\n", f.Name, f.Canonical, f.lineups,
+			100*float64(f.lineups)/float64(len(lineups)), strings.Join(f.members, " "))
+	}
+	if len(unparsed) > 0 {
+		fmt.Println("  not parsed:", strings.Join(unparsed, " "))
+	}
+
+	weights := make([]int, len(vocabulary))
+	for i, v := range vocabulary {
+		weights[i] = seen[v]
+	}
+	clusters := formation.ClusterFormations(vocabulary, weights, *k, *maxDistance)
+	fmt.Printf("\nClusters (%d, by formation distance):\n", len(clusters))
+	for _, c := range clusters {
+		fmt.Printf("  %-8s %5d  %s\n", c.Medoid, c.Weight, strings.Join(c.Members, " "))
+	}
+	fmt.Println("\nRun train, evaluate, join or ingame with -families to work at family level.")
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fusionform/formation"
+	"fusionform/formations"
+	"fusionform/recommender"
+)
+
+func runInGame(args []string) error {
+	fs := flag.NewFlagSet("ingame", flag.ExitOnError)
+	data := addDataFlags(fs)
+	data.addFamilies(fs)
+	score := fs.String("score", "0-0", "current score, ours first (e.g. 0-1 when a goal down)")
+	minute := fs.Int("minute", 0, "minutes played")
+	current := fs.String("current", "", "the formation we are playing now, to compare against")
+	top := fs.Int("top", 5, "number of formations to list")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata ingame [flags] <opponent formation>")
+		fmt.Fprintln(fs.Output(), "\nExample: fusiondata ingame -score 0-1 -minute 70 4-4-1-1")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one opponent formation, e.g. 4-4-1-1")
+	}
+	opp := formations.CleanFormation(fs.Arg(0))
+	state, err := parseScore(*score)
+	if err != nil {
+		return err
+	}
+	state.Minute = *minute
+	if state.Minute < 0 {
+		return fmt.Errorf("minute must not be negative")
+	}
+
+	if data.families {
+		opp = formation.Canonical(opp)
+	}
+	joined, err := data.load()
+	if err != nil {
+		return err
+	}
+	goals, err := recommender.FitGoals(recommender.Samples(joined.Fixtures))
+	if err != nil {
+		return err
+	}
+	recs, err := goals.RecommendFrom(opp, state, nil)
+	if err != nil {
+		return err
+	}
+
+	left := max(recommender.FullTime-state.Minute, 0)
+	fmt.Printf("%s against %s, %d minutes left:\n", state, opp, left)
+	for i, r := range recs {
+		if i == *top {
+			break
+		}
+		printStateRecommendation(fmt.Sprintf("%2d.", i+1), r)
+	}
+	if *current != "" {
+		cur := formations.CleanFormation(*current)
+		if data.families {
+			cur = formation.Canonical(cur)
+		}
+		stay, err := goals.RecommendFrom(opp, state, []string{cur})
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+		printStateRecommendation("Now", stay[0])
+	}
+	return nil
+}
+
+func printStateRecommendation(label string, r recommender.StateRecommendation) {
+	p := r.Probabilities
+	fmt.Printf("%-3s %-10s  xPts %.2f  (W %.0f%%  D %.0f%%  L %.0f%%)  goals %.2f-%.2f\n", label, r.Formation,
+		p.ExpectedPoints(), 100*p[recommender.Win], 100*p[recommender.Draw], 100*p[recommender.Loss],
+		r.GoalsFor, r.GoalsAgainst)
+}
+
+// parseScore reads a score line such as "1-0", ours first.
+func parseScore(s string) (recommender.GameState, error) {
+	ours, theirs, ok := strings.Cut(s, "-")
+	if !ok {
+		return recommender.GameState{}, fmt.Errorf("score %q must look like 1-0", s)
+	}
+	gf, err1 := strconv.Atoi(strings.TrimSpace(ours))
+	ga, err2 := strconv.Atoi(strings.TrimSpace(theirs))
+	if err1 != nil || err2 != nil || gf < 0 || ga < 0 {
+		return recommender.GameState{}, fmt.Errorf("score %q must look like 1-0", s)
+	}
+	return recommender.GameState{For: gf, Against: ga}, nil
+}
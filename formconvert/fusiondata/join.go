@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func runJoin(args []string) error {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	data := addDataFlags(fs)
+	data.addFamilies(fs)
+	showFixtures := fs.Bool("fixtures", false, "list every paired fixture")
+	fs.Parse(args)
+
+	joined, err := data.load()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Paired fixtures:    %d\n", len(joined.Fixtures))
+	fmt.Printf("Unmatched results:  %d\n", len(joined.UnmatchedMatches))
+	fmt.Printf("Unmatched games:    %d\n", len(joined.UnmatchedGames))
+	fmt.Printf("Orphan lineups:     %d\n", len(joined.Orphans))
+
+	if *showFixtures {
+		fmt.Println("\nFixtures:")
+		for _, f := range joined.Fixtures {
+			fmt.Printf("  %s  (%s v %s)\n", f.Match, f.Home.Formation, f.Away.Formation)
+		}
+	}
+	if len(joined.UnmatchedMatches) > 0 {
+		fmt.Println("\nUnmatched results:")
+		for _, u := range joined.UnmatchedMatches {
+			fmt.Printf("  row %d: %s: %s\n", u.Match.Row, u.Match, u.Reason)
+		}
+	}
+	if len(joined.UnmatchedGames) > 0 {
+		fmt.Println("\nFormation games without a result:")
+		for _, g := range joined.UnmatchedGames {
+			fmt.Printf("  gameid %d (%s): %s v %s\n", g.GameID, g.Season, g.Home.Team, g.Away.Team)
+		}
+	}
+	if len(joined.Orphans) > 0 {
+		fmt.Println("\nLineups without exactly one opponent:")
+		for _, l := range joined.Orphans {
+			fmt.Printf("  %s: gameid %d %s\n", l.Source, l.GameID, l.Team)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"fusionform/formation"
+)
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := fs.Int("format", 11, "players a side: 11, 8 or 7")
+	configPath := fs.String("config", "", "lint rules CSV (rule,format,value,severity) laid over the built-in rules")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata lint [flags] <formation>")
+		fmt.Fprintln(fs.Output(), "\nThe formation is read as the converters read it: line counts, a template")
+		fmt.Fprintln(fs.Output(), "name or a position list.")
+		fmt.Fprintln(fs.Output(), "\nExample: fusiondata lint 4-3-3")
+		fmt.Fprintln(fs.Output(), "         fusiondata lint -format 7 CD,CD,CD,CD,CD,ST")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one formation, e.g. 4-3-3")
+	}
+
+	config, err := formation.LoadLintConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("loading lint rules: %w", err)
+	}
+	roles, err := parseFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	findings := config.Lint(roles, *format)
+	fmt.Printf("%d-a-side %s: %s\n", *format, formation.ShapeOf(roles), roleList(roles))
+	if len(findings) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+	failed := 0
+	for _, f := range findings {
+		fmt.Println(" ", f)
+		if f.Severity == formation.Error || *strict {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d findings fail the lint", failed, len(findings))
+	}
+	return nil
+}
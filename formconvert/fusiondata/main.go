@@ -0,0 +1,62 @@
+// FusionForm data tools: command line access to the formations and results
+// datasets used to train the formation recommender.
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"join", "pair match results with both teams' formations", runJoin},
+	{"teams", "resolve team names through the alias registry", runTeams},
+	{"train", "train the formation recommender", runTrain},
+	{"recommend", "rank formations against an opponent's formation", runRecommend},
+	{"ingame", "rank formations from the current score and minute", runInGame},
+	{"transitions", "mine players seen in several lines for role compatibility", runTransitions},
+	{"reduce", "rework a formation after a red card or injury", runReduce},
+	{"lint", "check a formation against tactical rules", runLint},
+	{"track", "infer the formation held from a tracking export", runTrack},
+	{"similar", "list the known formations nearest to a formation", runSimilar},
+	{"families", "group the dataset's formations into families and clusters", runFamilies},
+	{"evaluate", "cross-validate the Go recommender against baselines", runEvaluate},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "FusionForm(data) V0.0.1")
+	fmt.Fprintln(os.Stderr, "© Deepfield 2025")
+	fmt.Fprintln(os.Stderr, "\nUsage: fusiondata <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'fusiondata <command> -h' for the flags of a command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	if os.Args[1] != "-h" && os.Args[1] != "help" {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+	}
+	usage()
+	os.Exit(2)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"fusionform/formation"
+	"fusionform/formations"
+	"fusionform/recommender"
+)
+
+func runRecommend(args []string) error {
+	fs := flag.NewFlagSet("recommend", flag.ExitOnError)
+	modelPath := fs.String("model", "formation_recommender.json", "model saved by 'fusiondata train'")
+	top := fs.Int("top", 5, "number of formations to list")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata recommend [flags] <opponent formation>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one opponent formation, e.g. 4-4-2")
+	}
+	opp := formations.CleanFormation(fs.Arg(0))
+
+	model, err := recommender.Load(*modelPath)
+	if err != nil {
+		return fmt.Errorf("loading model: %w", err)
+	}
+	if model.Trained.Families {
+		opp = formation.Canonical(opp)
+	}
+	if !model.Knows(opp) {
+		return fmt.Errorf("formation %q not recognised; known formations: %v", opp, model.Classes)
+	}
+	recs, err := model.Recommend(opp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Against %s:\n", opp)
+	for i, r := range recs {
+		if i == *top {
+			break
+		}
+		p := r.Probabilities
+		fmt.Printf("%2d. %-10s  xPts %.2f  (W %.0f%%  D %.0f%%  L %.0f%%)\n", i+1, r.Formation,
+			p.ExpectedPoints(), 100*p[recommender.Win], 100*p[recommender.Draw], 100*p[recommender.Loss])
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"fusionform/formation"
+)
+
+func runReduce(args []string) error {
+	fs := flag.NewFlagSet("reduce", flag.ExitOnError)
+	format := fs.Int("format", 11, "players a side: 11, 8 or 7")
+	n := fs.Int("n", 1, "players lost (red cards, or injuries with no substitutes left)")
+	priorityList := fs.String("priority", "def,mid,att", "lines to protect, most important first")
+	compatPath := fs.String("compat", "", "role compatibility CSV (from,to,score) laid over the built-in matrix")
+	explain := fs.Bool("explain", false, "print which player came off and why")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata reduce [flags] <formation>")
+		fmt.Fprintln(fs.Output(), "\nExample: fusiondata reduce -n 1 4-3-3")
+		fmt.Fprintln(fs.Output(), "         fusiondata reduce -format 7 -n 1 2-3-1")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one formation, e.g. 4-3-3")
+	}
+
+	roles, err := parseFormat(*format, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	compat, err := formation.LoadCompatibility(*compatPath)
+	if err != nil {
+		return fmt.Errorf("loading compatibility: %w", err)
+	}
+	priority, err := formation.ParsePriority(*priorityList)
+	if err != nil {
+		return err
+	}
+	opts := formation.Options{Compat: compat, Priority: priority}
+	if *explain {
+		opts.Trace = &formation.Trace{}
+	}
+
+	left, off, err := formation.Reduce(roles, *n, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d-a-side %s down to %d:\n", *format, formation.ShapeOf(roles), *format-*n)
+	fmt.Println("  Off: ", roleList(off))
+	fmt.Println("  Left:", roleList(left))
+	fmt.Printf("  Shape: %s", formation.ShapeOf(left))
+	if flex := formation.Counts(left)[formation.Flexible]; flex > 0 {
+		fmt.Printf(" (+%d Flex)", flex)
+	}
+	fmt.Println()
+	if opts.Trace != nil {
+		fmt.Println("\nTrace:")
+		fmt.Print(opts.Trace)
+	}
+	return nil
+}
+
+// parseFormat reads a formation of the given format (players a side) the
+// way the converters do; dashes in line counts are dropped, so 4-3-3 reads
+// as 433. An 8-a-side formation leaves out the Flex_8_1 placeholder, so it
+// holds the seven outfield players its lines name.
+func parseFormat(format int, s string) ([]formation.Role, error) {
+	if !strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, "-", "")
+	}
+	switch format {
+	case 11:
+		return formation.ParseNumericalFormationInput11(s)
+	case 8:
+		roles, err := formation.ParseNumericalFormationInput8(s)
+		return formation.WithoutFlex8(roles), err
+	case 7:
+		return formation.ParseNumericalFormationInput7(s)
+	}
+	return nil, fmt.Errorf("unsupported format %d (want 11, 8 or 7)", format)
+}
+
+func roleList(roles []formation.Role) string {
+	parts := make([]string, len(roles))
+	for i, r := range roles {
+		parts[i] = fmt.Sprintf("%s (%s)", r.Name, r.RoleType)
+	}
+	return strings.Join(parts, " ")
+}
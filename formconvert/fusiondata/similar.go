@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"fusionform/formation"
+	"fusionform/formations"
+)
+
+func runSimilar(args []string) error {
+	fs := flag.NewFlagSet("similar", flag.ExitOnError)
+	data := addDataFlags(fs)
+	top := fs.Int("top", 5, "number of formations to list")
+	minGames := fs.Int("min-games", 1, "ignore formations seen in fewer lineups")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata similar [flags] <formation>")
+		fmt.Fprintln(fs.Output(), "\nExample: fusiondata similar 4-1-4-1")
+		fmt.Fprintln(fs.Output(), "         fusiondata similar 451    (converter notation)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one formation, e.g. 4-1-4-1")
+	}
+	f := dashed(formations.CleanFormation(fs.Arg(0)))
+
+	names, err := data.registry()
+	if err != nil {
+		return err
+	}
+	lineups, err := formations.LoadDir(data.formationsDir, names)
+	if err != nil {
+		return fmt.Errorf("loading formations: %w", err)
+	}
+	seen := map[string]int{}
+	vocabulary := []string{}
+	for _, l := range lineups {
+		if seen[l.Formation] == 0 {
+			vocabulary = append(vocabulary, l.Formation)
+		}
+		seen[l.Formation]++
+	}
+	known := []string{}
+	for _, v := range vocabulary {
+		if seen[v] >= *minGames {
+			known = append(known, v)
+		}
+	}
+
+	matches, err := formation.Nearest(f, known, *top)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Nearest to %s (%d known formations):\n", f, len(known))
+	for i, m := range matches {
+		d := m.Distance
+		fmt.Printf("%2d. %-10s  distance %.3f  (lines %.3f  roles %.3f  positions %.3f)  %d lineups\n",
+			i+1, m.Formation, d.Total, d.Lines, d.Roles, d.Positions, seen[m.Formation])
+	}
+	return nil
+}
+
+// dashed turns the converters' digit notation ("451") into dashed notation;
+// anything else is returned as it is.
+func dashed(s string) string {
+	if strings.Contains(s, "-") || strings.Trim(s, "0123456789") != "" {
+		return s
+	}
+	return strings.Join(strings.Split(s, ""), "-")
+}
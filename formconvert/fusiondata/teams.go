@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func runTeams(args []string) error {
+	fs := flag.NewFlagSet("teams", flag.ExitOnError)
+	data := addDataFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata teams [flags] [name ...]")
+		fmt.Fprintln(fs.Output(), "Resolves each name to its canonical team, or lists every team when no names are given.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	names, err := data.registry()
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		for _, t := range names.Teams() {
+			fmt.Println(t)
+		}
+		return nil
+	}
+	for _, name := range fs.Args() {
+		if team, ok := names.Resolve(name); ok {
+			fmt.Printf("%s => %s\n", name, team)
+			continue
+		}
+		if s := names.Suggest(name, 3); len(s) > 0 {
+			fmt.Printf("%s: unknown team (did you mean %s?)\n", name, strings.Join(s, ", "))
+		} else {
+			fmt.Printf("%s: unknown team\n", name)
+		}
+	}
+	return nil
+}
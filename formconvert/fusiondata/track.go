@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"fusionform/tracking"
+)
+
+func runTrack(args []string) error {
+	fs := flag.NewFlagSet("track", flag.ExitOnError)
+	pitchSize := fs.String("pitch", "11", "pitch size in metres (LENGTHxWIDTH, e.g. 105x68) or 11, 8 or 7 for the standard pitch")
+	flip := fs.Bool("flip", false, "the team attacks towards x = 0")
+	keeper := fs.String("keeper", "", "player id of the goalkeeper (default: the deepest player when a full side is tracked)")
+	lines := fs.Int("lines", 0, "number of lines to split the outfield into (default: inferred)")
+	phases := fs.Int("phases", 1, "split the frames into this many phases of equal length")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fusiondata track [flags] <tracking.csv>")
+		fmt.Fprintln(fs.Output(), "\nThe CSV needs player, frame, x and y columns, positions in metres.")
+		fmt.Fprintln(fs.Output(), "\nExample: fusiondata track -phases 2 session.csv")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one tracking file")
+	}
+
+	pitch, err := tracking.ParsePitch(*pitchSize)
+	if err != nil {
+		return err
+	}
+	samples, err := tracking.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Samples read: %d\n", len(samples))
+
+	for i, phase := range tracking.Phases(samples, *phases, pitch, *flip) {
+		inf, err := tracking.Infer(phase.Positions, *keeper, *lines)
+		if err != nil {
+			return fmt.Errorf("phase %d: %w", i+1, err)
+		}
+		shape := inf.Shape()
+		fmt.Printf("\nPhase %d (frames %d-%d): %s\n", i+1, phase.From, phase.To, inf.Formation)
+		if inf.Keeper != "" {
+			fmt.Println("  Keeper:", inf.Keeper)
+		}
+		for j, line := range inf.Lines {
+			parts := make([]string, len(line))
+			for k, p := range line {
+				parts[k] = fmt.Sprintf("%s %s", p.Player, p.Pos)
+			}
+			fmt.Printf("  Line %d: %s\n", j+1, strings.Join(parts, "  "))
+		}
+		fmt.Println("  Roles: ", roleList(inf.Roles))
+		fmt.Printf("  Shape:  %s (converter input %d%d%d)\n", shape, shape[0], shape[1], shape[2])
+	}
+	return nil
+}
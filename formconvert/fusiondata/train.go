@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fusionform/recommender"
+)
+
+// modelFlags are the training hyperparameters shared by train and evaluate.
+type modelFlags struct {
+	cfg    recommender.Config
+	hidden string
+}
+
+func addModelFlags(fs *flag.FlagSet) *modelFlags {
+	m := &modelFlags{cfg: recommender.DefaultConfig()}
+	fs.StringVar(&m.hidden, "hidden", "16", "comma-separated hidden layer widths; empty for logistic regression")
+	fs.IntVar(&m.cfg.Epochs, "epochs", m.cfg.Epochs, "training epochs")
+	fs.IntVar(&m.cfg.BatchSize, "batch", m.cfg.BatchSize, "minibatch size")
+	fs.Float64Var(&m.cfg.LearningRate, "lr", m.cfg.LearningRate, "Adam learning rate")
+	fs.Float64Var(&m.cfg.L2, "l2", m.cfg.L2, "L2 weight decay")
+	fs.Int64Var(&m.cfg.Seed, "seed", m.cfg.Seed, "random seed")
+	return m
+}
+
+func (m *modelFlags) config() (recommender.Config, error) {
+	var err error
+	m.cfg.Hidden, err = parseWidths(m.hidden)
+	return m.cfg, err
+}
+
+func runTrain(args []string) error {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	data := addDataFlags(fs)
+	data.addFamilies(fs)
+	model := addModelFlags(fs)
+	out := fs.String("o", "formation_recommender.json", "where to save the trained model")
+	holdout := fs.Float64("holdout", 0, "fraction of games held out to report test loss (0 trains on everything)")
+	every := fs.Int("report", 20, "print the training loss every n epochs (0 for none)")
+	fs.Parse(args)
+
+	cfg, err := model.config()
+	if err != nil {
+		return err
+	}
+	if *holdout < 0 || *holdout >= 1 {
+		return fmt.Errorf("holdout must be in [0, 1)")
+	}
+
+	joined, err := data.load()
+	if err != nil {
+		return err
+	}
+	samples := recommender.Samples(joined.Fixtures)
+	cfg.Classes = recommender.Classes(samples)
+	if n := len(joined.UnmatchedMatches) + len(joined.UnmatchedGames); n > 0 {
+		fmt.Printf("Note: %d rows could not be joined; run 'fusiondata join' for details\n", n)
+	}
+
+	train, test := samples, []recommender.Sample(nil)
+	if *holdout > 0 {
+		train, test = recommender.Holdout(samples, *holdout, cfg.Seed)
+	}
+	fmt.Printf("Training on %d samples over %d formations\n", len(train), len(cfg.Classes))
+
+	trained, err := recommender.Train(train, cfg, func(epoch int, loss float64) {
+		if *every > 0 && (epoch%*every == 0 || epoch == cfg.Epochs) {
+			fmt.Printf("Epoch %d/%d, Train Loss: %.4f\n", epoch, cfg.Epochs, loss)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(test) > 0 {
+		loss, _ := trained.LogLoss(test)
+		fmt.Printf("Test Loss (%d samples): %.4f\n", len(test), loss)
+	}
+
+	trained.Trained.Families = data.families
+	if err := trained.Save(*out); err != nil {
+		return fmt.Errorf("saving model: %w", err)
+	}
+	fmt.Println("Model saved as", *out)
+	return nil
+}
+
+func parseWidths(s string) ([]int, error) {
+	widths := []int{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := strconv.Atoi(part)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid hidden layer width %q", part)
+		}
+		widths = append(widths, w)
+	}
+	return widths, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"fusionform/formations"
+	"fusionform/transitions"
+)
+
+func runTransitions(args []string) error {
+	fs := flag.NewFlagSet("transitions", flag.ExitOnError)
+	data := addDataFlags(fs)
+	minApps := fs.Int("min-apps", 3, "ignore players with fewer appearances")
+	top := fs.Float64("top", 0.9, "compatibility score of the most frequent role change")
+	minScore := fs.Float64("min-score", 0.05, "drop compatibility scores below this")
+	players := fs.Int("players", 10, "number of multi-line players to list")
+	out := fs.String("o", "", "write the learned compatibility table to this CSV (for the converters' -compat flag)")
+	fs.Parse(args)
+
+	names, err := data.registry()
+	if err != nil {
+		return err
+	}
+	lineups, err := formations.LoadDir(data.formationsDir, names)
+	if err != nil {
+		return fmt.Errorf("loading formations: %w", err)
+	}
+	records, skipped := transitions.Mine(lineups)
+	table := transitions.Transitions(records, *minApps)
+
+	fmt.Printf("Lineups read:       %d\n", len(lineups)-len(skipped))
+	fmt.Printf("Lineups skipped:    %d\n", len(skipped))
+	fmt.Printf("Players (%d+ apps):  %d\n", *minApps, table.Players)
+	fmt.Printf("Seen in 2+ lines:   %d\n", table.MultiLine)
+
+	fmt.Println("\nLine transitions (share of appearances by main line):")
+	fmt.Printf("%-5s", "")
+	for _, l := range transitions.Lines {
+		fmt.Printf(" %6s", l)
+	}
+	fmt.Println()
+	for _, a := range transitions.Lines {
+		fmt.Printf("%-5s", a)
+		for _, b := range transitions.Lines {
+			fmt.Printf(" %6.3f", table.LineFrequency(a, b))
+		}
+		fmt.Println()
+	}
+
+	roles := table.ObservedRoles()
+	fmt.Println("\nRole transitions (share of appearances by main role):")
+	fmt.Printf("%-5s", "")
+	for _, rt := range roles {
+		fmt.Printf(" %6s", rt)
+	}
+	fmt.Println()
+	for _, a := range roles {
+		fmt.Printf("%-5s", a)
+		for _, b := range roles {
+			fmt.Printf(" %6.3f", table.RoleFrequency(a, b))
+		}
+		fmt.Println()
+	}
+
+	if flexible := transitions.Flexible(records, *minApps); len(flexible) > 0 && *players > 0 {
+		fmt.Println("\nMost flexible players:")
+		for i, r := range flexible {
+			if i == *players {
+				break
+			}
+			fmt.Printf("  %-40s %3d apps  DEF %d  MID %d  ATT %d\n", r.Player, r.Apps,
+				r.Lines[transitions.Defence], r.Lines[transitions.Midfield], r.Lines[transitions.Attack])
+		}
+	}
+
+	compat := table.Compatibility(*top, *minScore)
+	if *out == "" {
+		fmt.Println("\nLearned compatibility (from,to,score):")
+		return compat.Write(os.Stdout)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(f, "# Learned from the players column by 'fusiondata transitions'.")
+	if err := compat.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println("\nCompatibility table saved as", *out)
+	return nil
+}
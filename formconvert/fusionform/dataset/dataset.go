@@ -0,0 +1,163 @@
+// Package dataset joins match results to the formations both teams used.
+//
+// The Python scripts merge results and formations on the cleaned team name
+// alone with inner joins, which pairs every match with every formation a team
+// ever used and silently drops anything that fails to match. Join instead
+// pairs each match with exactly one formation game and reports the rows on
+// either side that could not be paired.
+package dataset
+
+import (
+	"fmt"
+	"strings"
+
+	"fusionform/formations"
+	"fusionform/results"
+	"fusionform/teams"
+)
+
+// Game is a formations game: the two lineups recorded under one gameid. The
+// formation files list the home side first.
+type Game struct {
+	GameID int64
+	Season string
+	Home   formations.Lineup
+	Away   formations.Lineup
+}
+
+// Fixture is a match paired with both teams' formations.
+type Fixture struct {
+	Match results.Match
+	Home  formations.Lineup
+	Away  formations.Lineup
+}
+
+// Unmatched is a results row that could not be paired, with the reason.
+type Unmatched struct {
+	Match  results.Match
+	Reason string
+}
+
+// JoinResult holds the paired fixtures and everything left over.
+type JoinResult struct {
+	Fixtures         []Fixture
+	UnmatchedMatches []Unmatched
+	UnmatchedGames   []Game              // formation games with no result
+	Orphans          []formations.Lineup // lineups whose gameid does not have exactly two teams
+}
+
+// Games groups lineups by gameid. Lineups whose gameid does not have exactly
+// two rows are returned as orphans.
+func Games(lineups []formations.Lineup) ([]Game, []formations.Lineup) {
+	order := []int64{}
+	byID := map[int64][]formations.Lineup{}
+	for _, l := range lineups {
+		if _, seen := byID[l.GameID]; !seen {
+			order = append(order, l.GameID)
+		}
+		byID[l.GameID] = append(byID[l.GameID], l)
+	}
+
+	games := []Game{}
+	orphans := []formations.Lineup{}
+	for _, id := range order {
+		rows := byID[id]
+		if len(rows) != 2 {
+			orphans = append(orphans, rows...)
+			continue
+		}
+		games = append(games, Game{GameID: id, Season: rows[0].Season, Home: rows[0], Away: rows[1]})
+	}
+	return games, orphans
+}
+
+// Join pairs every match with its formation game. When the results carry a
+// gameid it is used directly; otherwise matches are paired by season, home
+// team and away team. Team names are compared through names, and results
+// naming a team the registry does not know are reported with suggestions.
+func Join(matches []results.Match, lineups []formations.Lineup, names *teams.Registry) JoinResult {
+	games, orphans := Games(lineups)
+	res := JoinResult{Orphans: orphans}
+
+	byID := map[int64]int{}
+	byTeams := map[string]int{}
+	seasons := map[string]bool{}
+	for i, g := range games {
+		byID[g.GameID] = i
+		byTeams[teamsKey(names, g.Season, g.Home.Team, g.Away.Team)] = i
+		seasons[g.Season] = true
+	}
+
+	used := make([]bool, len(games))
+	for _, m := range matches {
+		if m.Home == "" || m.Away == "" {
+			res.UnmatchedMatches = append(res.UnmatchedMatches, Unmatched{m, "missing team name"})
+			continue
+		}
+
+		var i int
+		var ok bool
+		if m.GameID != 0 {
+			if i, ok = byID[m.GameID]; !ok {
+				res.UnmatchedMatches = append(res.UnmatchedMatches, Unmatched{m, fmt.Sprintf("no formations for gameid %d", m.GameID)})
+				continue
+			}
+			if names.Canonical(games[i].Home.Team) != names.Canonical(m.Home) || names.Canonical(games[i].Away.Team) != names.Canonical(m.Away) {
+				res.UnmatchedMatches = append(res.UnmatchedMatches, Unmatched{m, fmt.Sprintf("gameid %d is %s v %s", m.GameID, games[i].Home.Team, games[i].Away.Team)})
+				continue
+			}
+		} else {
+			season := m.Season()
+			if !seasons[season] {
+				res.UnmatchedMatches = append(res.UnmatchedMatches, Unmatched{m, fmt.Sprintf("no formations for season %s", season)})
+				continue
+			}
+			if i, ok = byTeams[teamsKey(names, season, m.Home, m.Away)]; !ok {
+				reason := fmt.Sprintf("no formation game for %s v %s in %s", m.Home, m.Away, season)
+				if hint := unknownTeams(names, m.Home, m.Away); hint != "" {
+					reason += "; " + hint
+				}
+				res.UnmatchedMatches = append(res.UnmatchedMatches, Unmatched{m, reason})
+				continue
+			}
+		}
+
+		if used[i] {
+			res.UnmatchedMatches = append(res.UnmatchedMatches, Unmatched{m, fmt.Sprintf("duplicate result for gameid %d", games[i].GameID)})
+			continue
+		}
+		used[i] = true
+		res.Fixtures = append(res.Fixtures, Fixture{Match: m, Home: games[i].Home, Away: games[i].Away})
+	}
+
+	for i, g := range games {
+		if !used[i] {
+			res.UnmatchedGames = append(res.UnmatchedGames, g)
+		}
+	}
+	return res
+}
+
+func teamsKey(names *teams.Registry, season, home, away string) string {
+	return season + "|" + names.Canonical(home) + "|" + names.Canonical(away)
+}
+
+// unknownTeams describes the names the registry does not recognise, with the
+// closest known teams.
+func unknownTeams(names *teams.Registry, teamNames ...string) string {
+	if names == nil {
+		return ""
+	}
+	hints := []string{}
+	for _, name := range teamNames {
+		if _, ok := names.Resolve(name); ok {
+			continue
+		}
+		hint := fmt.Sprintf("unknown team %q", name)
+		if s := names.Suggest(name, 3); len(s) > 0 {
+			hint += fmt.Sprintf(" (did you mean %s?)", strings.Join(s, ", "))
+		}
+		hints = append(hints, hint)
+	}
+	return strings.Join(hints, "; ")
+}
@@ -0,0 +1,186 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// addedCost is the cost of a slot filled by a player the source formation
+// does not have, when converting up to a larger format.
+const addedCost = 0.5
+
+// Shape is a formation's player count per line: defenders, midfielders and
+// attackers.
+type Shape [3]int
+
+func (s Shape) String() string {
+	return fmt.Sprintf("%d-%d-%d", s[0], s[1], s[2])
+}
+
+// ShapeOf counts the lines of a role list. Flex roles belong to no line and
+// are left out.
+func ShapeOf(roles []Role) Shape {
+	c := Counts(roles)
+	return Shape{c[CenterBack] + c[FullBack], c[CentralMidfielder] + c[WideMidfielder], c[Striker]}
+}
+
+// Slots returns the role of every player in a shape. Eleven-a-side shapes
+// (10 outfield players or more) split their lines like RolesFromLines; small
+// sides play centre-backs only, and a midfield of three or more has wide
+// midfielders on the outside (one for a three, two for four or more).
+func (s Shape) Slots() []RoleType {
+	d, m, a := s[0], s[1], s[2]
+	if d+m+a >= 10 {
+		roles := RolesFromLines(d, m, a)
+		slots := make([]RoleType, len(roles))
+		for i, r := range roles {
+			slots[i] = r.RoleType
+		}
+		return slots
+	}
+	wide := 0
+	switch {
+	case m >= 4:
+		wide = 2
+	case m == 3:
+		wide = 1
+	}
+	slots := []RoleType{}
+	for i := 0; i < d; i++ {
+		slots = append(slots, CenterBack)
+	}
+	for i := 0; i < m-wide; i++ {
+		slots = append(slots, CentralMidfielder)
+	}
+	for i := 0; i < wide; i++ {
+		slots = append(slots, WideMidfielder)
+	}
+	for i := 0; i < a; i++ {
+		slots = append(slots, Striker)
+	}
+	return slots
+}
+
+// Candidate is one possible conversion, scored.
+type Candidate struct {
+	Shape   Shape
+	Roles   []Role
+	Fit     float64 // 1 minus the mean assignment cost per slot (capped at 1), in [0, 1]
+	Balance float64 // 1 minus the distance between the line shares, in [0, 1]
+	Score   float64 // mean of Fit and Balance
+	Reason  string
+}
+
+// Shapes lists the shapes with the given number of outfield players and at
+// least one player in every line. Eleven-a-side shapes are limited to three
+// to five defenders and one to three attackers.
+func Shapes(outfield int) []Shape {
+	shapes := []Shape{}
+	for d := 1; d < outfield; d++ {
+		for a := 1; d+a < outfield; a++ {
+			if outfield >= 10 && (d < 3 || d > 5 || a > 3) {
+				continue
+			}
+			shapes = append(shapes, Shape{d, outfield - d - a, a})
+		}
+	}
+	return shapes
+}
+
+// Alternatives scores every shape with the given number of outfield players
+// and returns the best k (all of them when k <= 0), best first. Each shape is
+// filled by optimal assignment (see ConvertOptimal); when the source has too
+// few players the rest are added, named after their slot ("CM_Add_1").
+//
+// A candidate scores well when its players sit in roles they cover (Fit)
+// and when it keeps the source's share of defenders, midfielders and
+// attackers (Balance), so a 5-3-2 and a 3-4-3 reduce differently.
+func Alternatives(source []Role, outfield, k int, opts Options) ([]Candidate, error) {
+	compat := opts.compat()
+	if outfield <= 2 {
+		return nil, fmt.Errorf("need at least 3 outfield players, got %d", outfield)
+	}
+	from := ShapeOf(source)
+	if from[0]+from[1]+from[2] == 0 {
+		return nil, fmt.Errorf("source formation has no players in any line")
+	}
+
+	candidates := []Candidate{}
+	for _, shape := range Shapes(outfield) {
+		c := fill(source, shape, compat)
+		c.Balance = 1 - shareDistance(from, shape)
+		c.Score = (c.Fit + c.Balance) / 2
+		c.Reason = reason(source, from, c)
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if k > 0 && k < len(candidates) {
+		candidates = candidates[:k]
+	}
+	return candidates, nil
+}
+
+// fill assigns the source roles to the shape's slots at least cost.
+func fill(source []Role, shape Shape, compat Compatibility) Candidate {
+	roles, costs := assignSlots(source, shape.Slots(), compat, nil)
+	total := 0.0
+	for _, cost := range costs {
+		total += math.Min(cost, 1) // an uncovered slot counts as one miss
+	}
+	return Candidate{Shape: shape, Roles: roles, Fit: 1 - total/float64(len(costs))}
+}
+
+// shareDistance is the total variation distance between the line shares of
+// two shapes.
+func shareDistance(a, b Shape) float64 {
+	na, nb := float64(a[0]+a[1]+a[2]), float64(b[0]+b[1]+b[2])
+	d := 0.0
+	for i := range a {
+		d += math.Abs(float64(a[i])/na - float64(b[i])/nb)
+	}
+	return d / 2
+}
+
+// reason is the short justification printed with a candidate.
+func reason(source []Role, from Shape, c Candidate) string {
+	parts := []string{}
+	switch {
+	case c.Balance >= 0.95:
+		parts = append(parts, fmt.Sprintf("keeps the %s balance", from))
+	case c.Shape[0]*(from[1]+from[2]) > from[0]*(c.Shape[1]+c.Shape[2]):
+		parts = append(parts, "more defensive than "+from.String())
+	case c.Shape[2]*(from[0]+from[1]) > from[2]*(c.Shape[0]+c.Shape[1]):
+		parts = append(parts, "more attacking than "+from.String())
+	default:
+		parts = append(parts, "weighted to midfield")
+	}
+
+	natural := 0
+	moves := []string{}
+	added := 0
+	types := map[string]RoleType{}
+	for _, r := range source {
+		types[r.Name] = r.RoleType
+	}
+	for _, r := range c.Roles {
+		src, ok := types[r.Name]
+		switch {
+		case !ok:
+			added++
+		case src == r.RoleType:
+			natural++
+		default:
+			moves = append(moves, fmt.Sprintf("%s to %s", r.Name, r.RoleType))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("%d of %d in their own role", natural, len(c.Roles)))
+	if len(moves) > 0 {
+		parts = append(parts, strings.Join(moves, ", "))
+	}
+	if added > 0 {
+		parts = append(parts, fmt.Sprintf("%d added", added))
+	}
+	return strings.Join(parts, "; ")
+}
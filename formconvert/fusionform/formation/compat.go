@@ -0,0 +1,162 @@
+package formation
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed compatibility.csv
+var defaultCompatibility string
+
+// RoleTypes lists every role type, back to front.
+var RoleTypes = []RoleType{CenterBack, FullBack, CentralMidfielder, WideMidfielder, Striker, Flexible}
+
+// ParseRoleType returns the role type with the given code ("CD", "FB"...).
+func ParseRoleType(s string) (RoleType, error) {
+	s = strings.TrimSpace(s)
+	for _, rt := range RoleTypes {
+		if strings.EqualFold(s, string(rt)) {
+			return rt, nil
+		}
+	}
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+// Compatibility says how well a player in one role covers another, from 0
+// (never) to 1 (natural position). It replaces the per-rule priority lists:
+// SelectRoles and every converter ask it who may fill a slot and in which
+// order, so tactical staff adjust who covers whom in one file.
+type Compatibility map[RoleType]map[RoleType]float64
+
+// DefaultCompatibility returns the built-in matrix. With it the relational
+// converters choose exactly the roles their former priority lists did.
+func DefaultCompatibility() Compatibility {
+	c := Compatibility{}
+	if err := c.Read(strings.NewReader(defaultCompatibility)); err != nil {
+		panic("formation: invalid built-in compatibility: " + err.Error())
+	}
+	return c
+}
+
+// LoadCompatibility returns the built-in matrix with the scores in a CSV file
+// (see Read) laid over it. An empty path gives the built-in matrix.
+func LoadCompatibility(path string) (Compatibility, error) {
+	c := DefaultCompatibility()
+	if path == "" {
+		return c, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := c.Read(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Read sets scores from CSV with a "from,to,score" header. A score of 0
+// removes a pair. Lines starting with # are comments.
+func (c Compatibility) Read(in io.Reader) error {
+	cr := csv.NewReader(in)
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) != 3 || strings.TrimSpace(header[0]) != "from" || strings.TrimSpace(header[1]) != "to" || strings.TrimSpace(header[2]) != "score" {
+		return fmt.Errorf("header must be \"from,to,score\"")
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		from, err := ParseRoleType(rec[0])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		to, err := ParseRoleType(rec[1])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		score, err := strconv.ParseFloat(strings.TrimSpace(rec[2]), 64)
+		if err != nil || score < 0 || score > 1 {
+			return fmt.Errorf("line %d: score must be between 0 and 1, got %q", line, rec[2])
+		}
+		c.Set(from, to, score)
+	}
+}
+
+// Write saves the matrix in the format Read expects, omitting the implicit
+// self scores.
+func (c Compatibility) Write(out io.Writer) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"from", "to", "score"})
+	for _, from := range RoleTypes {
+		for _, to := range RoleTypes {
+			score, ok := c[from][to]
+			if !ok || from == to && score == 1 {
+				continue
+			}
+			w.Write([]string{string(from), string(to), strconv.FormatFloat(score, 'f', -1, 64)})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Set records that from covers to with score.
+func (c Compatibility) Set(from, to RoleType, score float64) {
+	if c[from] == nil {
+		c[from] = map[RoleType]float64{}
+	}
+	c[from][to] = score
+}
+
+// Score is how well a player in role from covers role to. A role covers
+// itself with 1 unless the matrix says otherwise.
+func (c Compatibility) Score(from, to RoleType) float64 {
+	if score, ok := c[from][to]; ok {
+		return score
+	}
+	if from == to {
+		return 1
+	}
+	return 0
+}
+
+// Covers lists the role types that can fill a slot of role to, best first.
+func (c Compatibility) Covers(to RoleType) []RoleType {
+	covers := []RoleType{}
+	for _, from := range RoleTypes {
+		if c.Score(from, to) > 0 {
+			covers = append(covers, from)
+		}
+	}
+	sort.SliceStable(covers, func(i, j int) bool { return c.Score(covers[i], to) > c.Score(covers[j], to) })
+	return covers
+}
+
+// Best returns the role other than from that a player in role from covers
+// best, and false if there is none.
+func (c Compatibility) Best(from RoleType) (RoleType, bool) {
+	best, bestScore := RoleType(""), 0.0
+	for _, to := range RoleTypes {
+		if to != from && c.Score(from, to) > bestScore {
+			best, bestScore = to, c.Score(from, to)
+		}
+	}
+	return best, best != ""
+}
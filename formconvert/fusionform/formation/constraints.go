@@ -0,0 +1,375 @@
+package formation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A pinned player's cost is weighted by pinWeight, so pinned players get the
+// places they suit best, and has pinBonus taken off, so the assignment
+// places all of them before anyone else. pinBonus exceeds any weighted cost.
+const (
+	pinWeight = 100
+	pinBonus  = 1e6
+)
+
+// Constraints are a coach's requirements for a conversion.
+type Constraints struct {
+	Pinned []string         // names of source roles that must stay on the pitch, e.g. "CD_1"
+	Lines  *Shape           // players per line in the result; nil keeps the converter's
+	Forbid []RoleType       // role types the result may not use
+	Min    map[RoleType]int // at least this many of a role type in the result
+}
+
+// ParseConstraints reads constraints written as semicolon-separated clauses:
+//
+//	pin=CD_1,ST_2; lines=3-2-1; forbid=Flex,WM; min=ST:2
+//
+// Every clause is optional.
+func ParseConstraints(s string) (*Constraints, error) {
+	c := &Constraints{Min: map[RoleType]int{}}
+	for _, clause := range strings.Split(s, ";") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(clause, "=")
+		if !ok {
+			return nil, fmt.Errorf("constraint %q: want key=value", clause)
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "pin":
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					c.Pinned = append(c.Pinned, name)
+				}
+			}
+		case "lines":
+			counts, err := ParseLines(value)
+			if err != nil {
+				return nil, fmt.Errorf("constraint lines: %v", err)
+			}
+			if len(counts) != 3 {
+				return nil, fmt.Errorf("constraint lines: want defence-midfield-attack, got %q", value)
+			}
+			c.Lines = &Shape{counts[0], counts[1], counts[2]}
+		case "forbid":
+			for _, field := range strings.Split(value, ",") {
+				rt, err := ParseRoleType(strings.TrimSpace(field))
+				if err != nil {
+					return nil, fmt.Errorf("constraint forbid: %v", err)
+				}
+				c.Forbid = append(c.Forbid, rt)
+			}
+		case "min":
+			for _, field := range strings.Split(value, ",") {
+				name, n, ok := strings.Cut(strings.TrimSpace(field), ":")
+				if !ok {
+					return nil, fmt.Errorf("constraint min: want ROLE:n, got %q", field)
+				}
+				rt, err := ParseRoleType(name)
+				if err != nil {
+					return nil, fmt.Errorf("constraint min: %v", err)
+				}
+				count, err := strconv.Atoi(n)
+				if err != nil || count < 0 {
+					return nil, fmt.Errorf("constraint min: bad count %q for %s", n, rt)
+				}
+				c.Min[rt] = count
+			}
+		default:
+			return nil, fmt.Errorf("unknown constraint %q (want pin, lines, forbid or min)", key)
+		}
+	}
+	return c, nil
+}
+
+func (c *Constraints) forbids(rt RoleType) bool {
+	for _, f := range c.Forbid {
+		if f == rt {
+			return true
+		}
+	}
+	return false
+}
+
+// lineOf is the line a role type plays in, -1 for Flex.
+func lineOf(rt RoleType) int {
+	switch rt {
+	case CenterBack, FullBack:
+		return DefenceLine
+	case CentralMidfielder, WideMidfielder:
+		return MidfieldLine
+	case Striker:
+		return AttackLine
+	}
+	return -1
+}
+
+// lineOrder sorts role types back to front, Flex last.
+func lineOrder(rt RoleType) int {
+	if line := lineOf(rt); line >= 0 {
+		return line
+	}
+	return len(lineTypes)
+}
+
+// lineTypes are the role types of each line, the central one first.
+var lineTypes = [3][]RoleType{{CenterBack, FullBack}, {CentralMidfielder, WideMidfielder}, {Striker}}
+
+// slots applies the constraints to a converter's target slots: forced line
+// counts replace the target's shape, slots change type within a line (or
+// from Flex) until every minimum is met, and forbidden types give way to
+// another type of their line (a Flex slot to the role Flex covers best).
+// When a line is too short for its minimum and the lines are not forced,
+// it takes places from the other lines (see donor).
+func (c *Constraints) slots(target []RoleType, outfield int, compat Compatibility) ([]RoleType, error) {
+	slots := append([]RoleType{}, target...)
+	if c.Lines != nil {
+		if n := c.Lines[0] + c.Lines[1] + c.Lines[2]; n != outfield {
+			return nil, fmt.Errorf("lines %s have %d players, the format has %d outfield", c.Lines, n, outfield)
+		}
+		slots = c.Lines.Slots()
+	}
+
+	for _, rt := range RoleTypes {
+		need := c.Min[rt]
+		if need == 0 {
+			continue
+		}
+		if c.forbids(rt) {
+			return nil, fmt.Errorf("%s is both forbidden and required", rt)
+		}
+		have := Counts(roleSlots(slots))[rt]
+		line := lineOf(rt)
+		for i := range slots {
+			if have >= need || line < 0 {
+				break
+			}
+			if slots[i] == rt {
+				continue
+			}
+			if (slots[i] == Flexible || lineOf(slots[i]) == line) && Counts(roleSlots(slots))[slots[i]] > c.Min[slots[i]] {
+				slots[i] = rt
+				have++
+			}
+		}
+		for have < need && c.Lines == nil && line >= 0 {
+			i := c.donor(slots, line)
+			if i < 0 {
+				break
+			}
+			slots[i] = rt
+			have++
+			sort.SliceStable(slots, func(a, b int) bool { return lineOrder(slots[a]) < lineOrder(slots[b]) })
+		}
+		if have < need {
+			if c.Lines != nil {
+				return nil, fmt.Errorf("needs at least %d %s, but lines %s have room for %d", need, rt, c.Lines, have)
+			}
+			return nil, fmt.Errorf("needs at least %d %s, but the formation has room for %d", need, rt, have)
+		}
+	}
+
+	for i, slot := range slots {
+		if !c.forbids(slot) {
+			continue
+		}
+		replaced := false
+		if line := lineOf(slot); line >= 0 {
+			for _, rt := range lineTypes[line] {
+				if !c.forbids(rt) {
+					slots[i], replaced = rt, true
+					break
+				}
+			}
+		} else {
+			for _, rt := range compat.Covers(slot) {
+				if rt != slot && !c.forbids(rt) {
+					slots[i], replaced = rt, true
+					break
+				}
+			}
+		}
+		if !replaced {
+			return nil, fmt.Errorf("%s is forbidden and no other role can take its place", slot)
+		}
+	}
+	return slots, nil
+}
+
+// ConvertConstrained fills the converter's target for the given number of
+// outfield players (the style's 7- or 8-a-side target for 6 or 7, its
+// 11-a-side lines for 10) under opts.Constraints, by optimal assignment.
+// Pinned players are placed first; the rest go where they cost least. When
+// the source runs short, players are added and named after their slot.
+// It returns an error when the constraints cannot all be met.
+func ConvertConstrained(source []Role, outfield int, opts Options) ([]Role, error) {
+	c := opts.Constraints
+	if c == nil {
+		c = &Constraints{}
+	}
+	compat, style := opts.compat(), opts.style()
+	var target []RoleType
+	switch outfield {
+	case 6:
+		target = style.Target7()
+	case 7:
+		target = style.Target8()
+	case 10:
+		target = style.Lines.Slots()
+	default:
+		if c.Lines == nil {
+			return nil, fmt.Errorf("no default shape for %d outfield players; give the lines", outfield)
+		}
+	}
+	slots, err := c.slots(target, outfield, compat)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := map[string]bool{}
+	names := map[string]bool{}
+	for _, r := range source {
+		names[r.Name] = true
+	}
+	for _, name := range c.Pinned {
+		if !names[name] {
+			return nil, fmt.Errorf("pinned player %s is not in the source formation", name)
+		}
+		pinned[name] = true
+	}
+	if len(pinned) > len(slots) {
+		return nil, fmt.Errorf("%d players pinned, but only %d places", len(pinned), len(slots))
+	}
+
+	roles, costs := assignSlots(source, slots, compat, pinned)
+	types := map[string]RoleType{}
+	for _, r := range source {
+		types[r.Name] = r.RoleType
+	}
+	for i, r := range roles {
+		if pinned[r.Name] && costs[i] >= uncoveredCost {
+			return nil, fmt.Errorf("pinned player %s (%s) cannot cover any place left for them", r.Name, types[r.Name])
+		}
+	}
+
+	if opts.Trace != nil {
+		opts.Trace.Add(Step{
+			Rule:   "Constraints: places",
+			Reason: fmt.Sprintf("fills %s", describeAdded(roleSlots(slots))),
+		})
+		placed := []Role{}
+		for _, r := range roles {
+			if pinned[r.Name] {
+				placed = append(placed, r)
+			}
+		}
+		if len(placed) > 0 {
+			opts.Trace.Add(Step{Rule: "Constraints: pinned", Chosen: placed, Reason: "pinned players are placed first"})
+		}
+		opts.Trace.Add(Step{Rule: "Constraints: assignment", Chosen: roles, Reason: "everyone else goes where they cost least"})
+		kept := map[string]bool{}
+		for _, r := range roles {
+			kept[r.Name] = true
+		}
+		dropped := []Role{}
+		for _, r := range source {
+			if !kept[r.Name] {
+				dropped = append(dropped, r)
+			}
+		}
+		opts.Trace.Add(Step{Rule: "Dropped", Chosen: dropped, Reason: "no place left for these roles"})
+	}
+	return roles, nil
+}
+
+// roleSlots turns slots into roles so they can be counted.
+// donor is the slot a minimum in line takes from another line: the last
+// spare slot of the largest other line, the nearer one on a tie. A slot is
+// spare while its type is above its own minimum, and a line keeps at least
+// one player. It returns -1 when no slot is spare.
+func (c *Constraints) donor(slots []RoleType, line int) int {
+	counts := Counts(roleSlots(slots))
+	size := [3]int{}
+	for _, slot := range slots {
+		if l := lineOf(slot); l >= 0 {
+			size[l]++
+		}
+	}
+	distance := func(l int) int { return max(l-line, line-l) }
+	best, bestLine := -1, -1
+	for i, slot := range slots {
+		l := lineOf(slot)
+		if l < 0 || l == line || size[l] <= 1 || counts[slot] <= c.Min[slot] {
+			continue
+		}
+		if bestLine < 0 || size[l] > size[bestLine] || size[l] == size[bestLine] && distance(l) <= distance(bestLine) {
+			best, bestLine = i, l
+		}
+	}
+	return best
+}
+
+func roleSlots(slots []RoleType) []Role {
+	roles := make([]Role, len(slots))
+	for i, slot := range slots {
+		roles[i] = Role{RoleType: slot}
+	}
+	return roles
+}
+
+// misfitCost is compat.Cost, except that a move the matrix does not cover
+// costs more the more lines it takes the player from their own, so a spare
+// centre-back who has to play somewhere goes to full-back rather than up
+// front.
+func misfitCost(compat Compatibility, from, to RoleType) float64 {
+	cost := compat.Cost(from, to)
+	if a, b := lineOf(from), lineOf(to); cost >= uncoveredCost && a >= 0 && b >= 0 {
+		cost += float64(max(a-b, b-a))
+	}
+	return cost
+}
+
+// assignSlots fills slots from source at least cost, returning the roles and
+// the cost of each slot. Source players keep their names and positions. When the source is short, the remaining slots get
+// players named after their slot ("CM_Add_1"). Roles whose names are pinned
+// are placed before anyone else.
+func assignSlots(source []Role, slots []RoleType, compat Compatibility, pinned map[string]bool) ([]Role, []float64) {
+	columns := max(len(source), len(slots))
+	cost := make([][]float64, len(slots))
+	for i, slot := range slots {
+		cost[i] = make([]float64, columns)
+		for j := range cost[i] {
+			switch {
+			case j >= len(source):
+				cost[i][j] = addedCost
+			case pinned[source[j].Name]:
+				cost[i][j] = pinWeight*compat.Cost(source[j].RoleType, slot) - pinBonus
+			default:
+				cost[i][j] = misfitCost(compat, source[j].RoleType, slot)
+			}
+		}
+	}
+	assigned := hungarian(cost)
+
+	roles := make([]Role, len(slots))
+	costs := make([]float64, len(slots))
+	added := map[RoleType]int{}
+	for i, slot := range slots {
+		j := assigned[i]
+		if j < len(source) {
+			from := source[j]
+			roles[i] = Role{RoleType: slot, Name: from.Name, Pos: from.Pos}
+			costs[i] = compat.Cost(from.RoleType, slot)
+			continue
+		}
+		added[slot]++
+		roles[i] = Role{RoleType: slot, Name: fmt.Sprintf("%s_Add_%d", slot, added[slot])}
+		costs[i] = addedCost
+	}
+	return roles, costs
+}
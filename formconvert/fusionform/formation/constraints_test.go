@@ -0,0 +1,25 @@
+package formation
+
+import "testing"
+
+func TestMinTakesPlacesFromOtherLines(t *testing.T) {
+	c, err := ParseConstraints("min=ST:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slots, err := c.slots(Shape{4, 4, 2}.Slots(), 10, DefaultCompatibility())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ShapeOf(roleSlots(slots)); got != (Shape{3, 3, 4}) {
+		t.Errorf("min=ST:4 on 4-4-2 gives %s, want 3-3-4", got)
+	}
+
+	c, err = ParseConstraints("lines=4-4-2; min=ST:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.slots(nil, 10, DefaultCompatibility()); err == nil {
+		t.Error("min=ST:4 with lines=4-4-2: no error")
+	}
+}
@@ -0,0 +1,344 @@
+package formation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Convert11to7Relational applies the relational 11→7 rules: 2 CD, 2 CM, 1 ST
+// and a Flex slot, or the line counts of opts.Style. Rules 2 and 3 take
+// natural players first and then any cover in formation order, not by score.
+func Convert11to7Relational(formation11 []Role, opts Options) ([]Role, error) {
+	compat, trace, lines := opts.compat(), opts.Trace, opts.style().Lines7
+	roles7 := []Role{}
+	remainingRoles11 := formation11
+
+	// Rule 1: Preserve Central Defensive Core (2 CD)
+	selectedCDs := pick(trace, fmt.Sprintf("Rule 1: Preserve Central Defensive Core (%d CD)", lines[0]), remainingRoles11, lines[0], CenterBack, compat)
+	roles7 = append(roles7, selectedCDs...)
+	remainingRoles11 = RemoveRoles(remainingRoles11, selectedCDs)
+
+	// Rule 2: Maintain Central Midfield Control (2 CM)
+	selectedCMs := pickNatural(trace, fmt.Sprintf("Rule 2: Maintain Central Midfield Control (%d CM)", lines[1]), remainingRoles11, lines[1], CentralMidfielder, compat)
+	roles7 = append(roles7, selectedCMs...)
+	remainingRoles11 = RemoveRoles(remainingRoles11, selectedCMs)
+
+	// Rule 3: Create an Attacking Focus (1 ST)
+	selectedST := pickNatural(trace, fmt.Sprintf("Rule 3: Create an Attacking Focus (%d ST)", lines[2]), remainingRoles11, lines[2], Striker, compat)
+	roles7 = append(roles7, selectedST...)
+	remainingRoles11 = RemoveRoles(remainingRoles11, selectedST)
+
+	// Rule 4: Adapt Remaining Roles for Flexibility (1 Flex - if needed to reach 6 outfield)
+	if len(roles7) < 6 && len(remainingRoles11) > 0 { // Ensure 6 outfield players in 7-a-side
+		selectedFlex := pick(trace, "Rule 4: Adapt Remaining Roles for Flexibility (1 Flex)", remainingRoles11, 1, Flexible, compat) // Width/balance first: WM, FB
+		if len(selectedFlex) > 0 {
+			roles7 = append(roles7, Role{RoleType: Flexible, Name: "Flex_1"}) // Assign a flexible role
+			if trace != nil {
+				trace.Steps[len(trace.Steps)-1].Reason += "; plays on as Flex_1"
+			}
+			remainingRoles11 = RemoveRoles(remainingRoles11, selectedFlex)
+		}
+	}
+	trace.Add(Step{Rule: "Dropped", Chosen: remainingRoles11, Reason: "no 7-a-side slot left for these roles"})
+
+	return roles7, nil
+}
+
+// Convert11to8Relational applies the relational 11→8 rules: 2 CD, 3 CM/WM and
+// 2 ST, or the line counts of opts.Style.
+func Convert11to8Relational(formation11 []Role, opts Options) ([]Role, error) {
+	compat, trace, lines := opts.compat(), opts.Trace, opts.style().Lines8
+	roles8 := []Role{}
+	remainingRoles11 := formation11
+
+	selectedCDs := pick(trace, fmt.Sprintf("Rule 1: Central defenders (%d CD)", lines[0]), remainingRoles11, lines[0], CenterBack, compat)
+	roles8 = append(roles8, selectedCDs...)
+	remainingRoles11 = RemoveRoles(remainingRoles11, selectedCDs)
+
+	selectedCMs := pick(trace, fmt.Sprintf("Rule 2: Midfield (%d CM/WM)", lines[1]), remainingRoles11, lines[1], CentralMidfielder, compat)
+	roles8 = append(roles8, selectedCMs...)
+	remainingRoles11 = RemoveRoles(remainingRoles11, selectedCMs)
+
+	selectedSTs := pick(trace, fmt.Sprintf("Rule 3: Strikers (%d ST)", lines[2]), remainingRoles11, lines[2], Striker, compat)
+	roles8 = append(roles8, selectedSTs...)
+	remainingRoles11 = RemoveRoles(remainingRoles11, selectedSTs)
+
+	if len(roles8) < 7 && len(remainingRoles11) > 0 {
+		selectedFlex := SelectRoles(remainingRoles11, 1, Flexible, compat)
+		if len(selectedFlex) > 0 {
+			// roles8 = append(roles8, Role{RoleType: Flexible, Name: "Flex_Outfield_1"})
+		}
+	}
+	trace.Add(Step{Rule: "Dropped", Chosen: remainingRoles11, Reason: "no 8-a-side slot left for these roles"})
+
+	// roles8 = append(roles8, Role{RoleType: Goalkeeper, Name: "GK_1"})
+	return roles8, nil
+}
+
+// Convert7to11RuleBased expands a 7-a-side formation to a back four, a
+// midfield four and two strikers (or the lines of opts.Style), turning each
+// Flex role into the role it covers best (a wide midfielder with the built-in
+// matrix).
+func Convert7to11RuleBased(formation7 []Role, opts Options) ([]Role, error) {
+	compat, trace, style := opts.compat(), opts.Trace, opts.style()
+	roles11 := []Role{}
+	counts7 := make(map[RoleType]int)
+	for _, role := range formation7 {
+		counts7[role.RoleType]++
+	}
+
+	cd7 := counts7[CenterBack]
+	cm7 := counts7[CentralMidfielder]
+	st7 := counts7[Striker]
+	flex7 := counts7[Flexible]     // If used
+	fb7 := counts7[FullBack]       // Only when a Flex was resolved to one
+	wm7 := counts7[WideMidfielder] // Likewise
+
+	// Rule 1: Expand Defense to 4 (Transform CD to CDs and add FBs if needed)
+	mark := len(roles11)
+	defenders11Needed := style.Lines[0]
+	defenders7 := min(cd7, defenders11Needed) // Limit CDs if 7-a-side had too many for the back line
+	fullbacks7 := min(fb7, defenders11Needed-defenders7)
+	roles11 = append(roles11, expandLine(style, 0, CenterBack, defenders7, FullBack, fullbacks7, defenders11Needed)...)
+	traceExpansion(trace, fmt.Sprintf("Rule 1: Expand Defense to %d", defenders11Needed), formation7, CenterBack, defenders7, roles11[mark:],
+		fmt.Sprintf("keeps %d CD and %d FB and adds %s for a back %d", defenders7, fullbacks7, describeAdded(roles11[mark+defenders7+fullbacks7:]), defenders11Needed))
+
+	// Rule 2: Expand Midfield to 4 (or 5 if space allows, Transform CMs and add WMs)
+	mark = len(roles11)
+	midfielders11Needed := style.Lines[1]
+	midfielders7 := min(cm7, midfielders11Needed) // Limit CMs if 7-a-side had too many
+	wide7 := min(wm7, midfielders11Needed-midfielders7)
+	roles11 = append(roles11, expandLine(style, 1, CentralMidfielder, midfielders7, WideMidfielder, wide7, midfielders11Needed)...)
+	traceExpansion(trace, fmt.Sprintf("Rule 2: Expand Midfield to %d", midfielders11Needed), formation7, CentralMidfielder, midfielders7, roles11[mark:],
+		fmt.Sprintf("keeps %d CM and %d WM and adds %s for a midfield %d", midfielders7, wide7, describeAdded(roles11[mark+midfielders7+wide7:]), midfielders11Needed))
+
+	// Rule 3: Keep Strikers (Aim for 2, adjust midfield/defense if needed to keep total around 10 outfield)
+	mark = len(roles11)
+	strikers11Needed := style.Lines[2]
+	strikers7 := min(st7, strikers11Needed) // Limit strikers if 7-a-side had too many
+	roles11 = append(roles11, expandLine(style, 2, Striker, strikers7, "", 0, strikers11Needed)...)
+	traceExpansion(trace, fmt.Sprintf("Rule 3: Keep Strikers (%d ST)", strikers11Needed), formation7, Striker, strikers7, roles11[mark:],
+		fmt.Sprintf("keeps %d ST and adds %s", strikers7, describeAdded(roles11[mark+strikers7:])))
+
+	// Rule 4:  Handle Flexible Roles (Convert Flex to Wide Midfielder if needed, or adjust midfield count)
+	flexibleRolesToAdd := flex7 // Bring over flexible roles
+	flexAs, ok := compat.Best(Flexible)
+	if !ok {
+		flexAs = WideMidfielder
+	}
+	// Try to convert flexible roles to Wide Midfielders if there's space to reach 10 outfield players
+	mark = len(roles11)
+	if flexibleRolesToAdd > 0 && (len(roles11)+flexibleRolesToAdd <= 10) { // Check if adding flex roles keeps total <= 10
+		for i := 0; i < flexibleRolesToAdd; i++ {
+			roles11 = append(roles11, Role{RoleType: flexAs, Name: fmt.Sprintf("Flex%s_%d", flexAs, i+1)}) // Convert Flex to WM
+		}
+		traceExpansion(trace, "Rule 4: Handle Flexible Roles", formation7, Flexible, flexibleRolesToAdd, roles11[mark:],
+			fmt.Sprintf("Flex covers %s best (%.2g)", flexAs, compat.Score(Flexible, flexAs)))
+	} else if flexibleRolesToAdd > 0 {
+		traceExpansion(trace, "Rule 4: Handle Flexible Roles", formation7, Flexible, 0, nil, "no room left in the 10 outfield places")
+	}
+
+	// **Final Adjustment: Ensure Total Outfield Players is Exactly 10**
+	currentOutfieldPlayers := len(roles11)
+	mark = len(roles11)
+	if currentOutfieldPlayers > 10 {
+		trace.Add(Step{Rule: "Final Adjustment", Chosen: []Role{}, Rejected: roles11[10:], Reason: "more than 10 outfield players"})
+		roles11 = roles11[:10] // Truncate if somehow we exceeded 10 (safety measure, should not happen with rules above)
+	} else if currentOutfieldPlayers < 10 {
+		// Add more midfielders (Central Midfielders as default for filling gaps) to reach 10
+		midfieldersNeededToFill := 10 - currentOutfieldPlayers
+		for i := 0; i < midfieldersNeededToFill; i++ {
+			roles11 = append(roles11, Role{RoleType: CentralMidfielder, Name: fmt.Sprintf("CM_Fill_%d", i+1)}) // Fill with CMs
+		}
+		trace.Add(Step{Rule: "Final Adjustment", Chosen: roles11[mark:], Reason: fmt.Sprintf("fills the last %d places with CM", midfieldersNeededToFill)})
+	}
+
+	return roles11, nil
+}
+
+// expandLine builds one 11-a-side line: kept players of the line's own role
+// and of its wide role, then the style's added roles up to target. Added
+// players of the line's own role are named "_Extra" ("ST_Extra_1"), others by
+// role, numbered on from the kept ones ("FB_2").
+func expandLine(style *Style, line int, own RoleType, kept int, wide RoleType, keptWide, target int) []Role {
+	roles := []Role{}
+	for i := 0; i < kept; i++ {
+		roles = append(roles, Role{RoleType: own, Name: fmt.Sprintf("%s_%d", own, i+1)})
+	}
+	for i := 0; i < keptWide; i++ {
+		roles = append(roles, Role{RoleType: wide, Name: fmt.Sprintf("%s_%d", wide, i+1)})
+	}
+	added := map[RoleType]int{wide: keptWide}
+	for i := 0; i < target-kept-keptWide; i++ {
+		rt := style.add(line, i)
+		added[rt]++
+		name := fmt.Sprintf("%s_%d", rt, added[rt])
+		if rt == own {
+			name = fmt.Sprintf("%s_Extra_%d", rt, added[rt])
+		}
+		roles = append(roles, Role{RoleType: rt, Name: name})
+	}
+	return roles
+}
+
+// describeAdded counts added roles for a trace, e.g. "2 FB, 1 CD".
+func describeAdded(roles []Role) string {
+	if len(roles) == 0 {
+		return "none"
+	}
+	parts := []string{}
+	counts := Counts(roles)
+	for _, rt := range RoleTypes {
+		if counts[rt] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[rt], rt))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// traceExpansion records a 7→11 rule that keeps up to kept source roles of
+// one type and creates the rest of its line.
+func traceExpansion(trace *Trace, rule string, source []Role, rt RoleType, kept int, placed []Role, reason string) {
+	if trace == nil {
+		return
+	}
+	candidates := FilterRoles(source, []RoleType{rt})
+	kept = min(kept, len(candidates))
+	trace.Add(Step{Rule: rule, Candidates: candidates, Chosen: placed, Rejected: candidates[kept:], Reason: reason})
+}
+
+// Convert8to11Relational expands an 8-a-side formation to 11-a-side, growing
+// each line to the counts of opts.Style (4-4-2 by default) with the style's
+// roles taken first from the flexible pool.
+func Convert8to11Relational(formation8 []Role, opts Options) ([]Role, error) {
+	compat, trace, style := opts.compat(), opts.Trace, opts.style()
+	roles11 := []Role{}
+	remainingRoles8 := formation8
+
+	// Categorize 8-a-side roles for easier processing based on assumed 8-a-side conversion logic output
+	cdRoles8 := FilterRoles(remainingRoles8, []RoleType{CenterBack})
+	cmRoles8 := FilterRoles(remainingRoles8, []RoleType{CentralMidfielder})
+	stRoles8 := FilterRoles(remainingRoles8, []RoleType{Striker})
+	flexRoles8 := FilterRoles(remainingRoles8, []RoleType{Flexible, WideMidfielder, FullBack, Striker, CentralMidfielder, CenterBack}) // Flex could be anything
+
+	// grow takes up to n roles for a line from the flexible pool, trying the
+	// style's roles for the line in order.
+	grow := func(rule string, line int, own RoleType, n int) {
+		for _, rt := range style.addOrder(line, own) {
+			if n <= 0 {
+				break
+			}
+			selected := pick(trace, fmt.Sprintf("%s - %s", rule, roleNames[rt]), flexRoles8, n, rt, compat)
+			roles11 = append(roles11, settleFlex(trace, selected, rt)...)
+			flexRoles8 = RemoveRoles(flexRoles8, selected) // Update flex roles
+			n -= len(selected)
+		}
+	}
+
+	// Rule 1: Expand Defense to 4 (or 3) Defenders - Prioritize adding Full-Backs
+	grow(fmt.Sprintf("Rule 1: Expand Defense to %d", style.Lines[0]), 0, CenterBack, style.Lines[0]-len(cdRoles8))
+	roles11 = append(roles11, cdRoles8...) // Add the original CD roles from 8-a-side
+	trace.Add(Step{Rule: "Rule 1: Keep the 8-a-side CDs", Chosen: cdRoles8, Reason: "original defenders carry over"})
+
+	// Rule 2: Expand Midfield to 4 (or 5) Midfielders - Prioritize Wide Midfielders for width
+	grow(fmt.Sprintf("Rule 2: Expand Midfield to %d", style.Lines[1]), 1, CentralMidfielder, style.Lines[1]-len(cmRoles8))
+	roles11 = append(roles11, cmRoles8...) // Add original CM roles
+	trace.Add(Step{Rule: "Rule 2: Keep the 8-a-side CMs", Chosen: cmRoles8, Reason: "original midfielders carry over"})
+
+	// Rule 3: Maintain/Adjust Attack - Maybe add one more Striker or Wide Midfielder for attacking width
+	grow("Rule 3: Maintain/Adjust Attack", 2, Striker, style.Lines[2]-len(stRoles8)) // Prioritize ST, then WM
+	roles11 = append(roles11, stRoles8...)                                           // Add original ST roles
+	trace.Add(Step{Rule: "Rule 3: Keep the 8-a-side STs", Chosen: stRoles8, Reason: "original strikers carry over"})
+
+	// Rule 4: Fill Remaining Slots - Prioritize Full-Backs/Wide Midfielders for balance, then Central Midfielders
+	rolesToAddFinal := 10 - len(roles11) // Calculate remaining outfield players needed (10 total outfield in 11-a-side)
+	if rolesToAddFinal > 0 {
+		remainingFlex := flexRoles8       // Use remaining flexible roles
+		for _, slot := range style.Fill { // The style's order, FB, WM, CM when balanced
+			selectedFinalRoles := pick(trace, "Rule 4: Fill Remaining Slots - "+string(slot), remainingFlex, rolesToAddFinal, slot, compat)
+			roles11 = append(roles11, settleFlex(trace, selectedFinalRoles, slot)...)
+			remainingFlex = RemoveRoles(remainingFlex, selectedFinalRoles)
+			rolesToAddFinal -= len(selectedFinalRoles)
+		}
+	}
+
+	// Goalkeeper
+	// roles11 = append(roles11, Role{RoleType: Flexible, Name: "GK_1"})
+
+	return roles11, nil
+}
+
+// settleFlex gives the Flex players among selected the role of the slot they
+// were picked for, as an 11-a-side formation has no Flex role.
+func settleFlex(trace *Trace, selected []Role, slot RoleType) []Role {
+	settled := make([]Role, len(selected))
+	for i, r := range selected {
+		settled[i] = r
+		if r.RoleType == Flexible {
+			settled[i].RoleType = slot
+			if trace != nil {
+				trace.Steps[len(trace.Steps)-1].Reason += fmt.Sprintf("; %s plays %s", r.Name, slot)
+			}
+		}
+	}
+	return settled
+}
+
+// Converter turns a formation's roles into another format's, or reports why
+// it cannot.
+type Converter func(roles []Role, opts Options) ([]Role, error)
+
+// Strategies11to7, Strategies11to8, Strategies7to11 and Strategies8to11 are
+// the converters the command line tools can choose between, by name.
+var (
+	Strategies11to7 = map[string]Converter{
+		"greedy":       Convert11to7Relational,
+		"optimal":      Convert11to7Optimal,
+		"proportional": Convert11to7Proportional,
+		"partnership":  Convert11to7Partnership,
+		"geometric":    Convert11to7Geometric,
+		"sided":        Convert11to7Sided,
+	}
+	Strategies11to8 = map[string]Converter{
+		"greedy":       Convert11to8Relational,
+		"optimal":      Convert11to8Optimal,
+		"proportional": Convert11to8Proportional,
+		"partnership":  Convert11to8Partnership,
+		"geometric":    Convert11to8Geometric,
+		"sided":        Convert11to8Sided,
+	}
+	Strategies7to11 = map[string]Converter{
+		"greedy":       Convert7to11RuleBased,
+		"proportional": Convert7to11Proportional,
+		"geometric":    Convert7to11Geometric,
+		"sided":        Convert7to11Sided,
+	}
+	Strategies8to11 = map[string]Converter{
+		"greedy":       Convert8to11Relational,
+		"proportional": Convert8to11Proportional,
+		"geometric":    Convert8to11Geometric,
+		"sided":        Convert8to11Sided,
+	}
+)
+
+// StrategyLabels are the headings the command line tools print over a
+// conversion, by strategy name. The greedy label differs per tool.
+var StrategyLabels = map[string]string{
+	"optimal":      "Optimal Assignment",
+	"proportional": "Proportional Scaling",
+	"constrained":  "Constrained Assignment",
+	"partnership":  "Partnership-Preserving Assignment",
+	"geometric":    "Geometric Assignment",
+	"sided":        "Side-Keeping Assignment",
+}
+
+// StrategyNames lists the names in a strategy table, sorted.
+func StrategyNames(strategies map[string]Converter) []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
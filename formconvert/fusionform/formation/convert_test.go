@@ -0,0 +1,42 @@
+package formation
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRelationalKeepsPriorityLists pins the relational converters to the
+// roles their per-rule priority lists chose before the compatibility matrix
+// replaced them.
+func TestRelationalKeepsPriorityLists(t *testing.T) {
+	tests := []struct {
+		input   string
+		parse   func(string) ([]Role, error)
+		convert Converter
+		want    string
+	}{
+		{"442", ParseNumericalFormationInput11, Convert11to7Relational, "CD_1 (CD) CD_2 (CD) CM_1 (CM) CM_2 (CM) ST_1 (ST) Flex_1 (Flex)"},
+		{"550", ParseNumericalFormationInput11, Convert11to7Relational, "CD_1 (CD) CD_2 (CD) CM_1 (CM) CM_2 (CM) FB_1 (FB) Flex_1 (Flex)"},
+		{"451", ParseNumericalFormationInput11, Convert11to8Relational, "CD_1 (CD) CD_2 (CD) CM_1 (CM) CM_2 (CM) CM_3 (CM) ST_1 (ST) WM_1 (WM)"},
+		{"550", ParseNumericalFormationInput11, Convert11to8Relational, "CD_1 (CD) CD_2 (CD) CM_1 (CM) CM_2 (CM) CM_3 (CM) WM_1 (WM) WM_2 (WM)"},
+		{"232", ParseNumericalFormationInput8, Convert8to11Relational, "CD_8_1 (CD) CD_8_2 (CD) CD_8_1 (CD) CD_8_2 (CD) CM_8_1 (CM) CM_8_1 (CM) CM_8_2 (CM) CM_8_3 (CM) ST_8_1 (ST) ST_8_2 (ST)"},
+		{"321", ParseNumericalFormationInput7, Convert7to11RuleBased, "CD_1 (CD) CD_2 (CD) CD_3 (CD) FB_1 (FB) CM_1 (CM) CM_2 (CM) WM_1 (WM) WM_2 (WM) ST_1 (ST) ST_Extra_1 (ST)"},
+	}
+	for _, tt := range tests {
+		source, err := tt.parse(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		roles, err := tt.convert(source, Options{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		got := []string{}
+		for _, r := range roles {
+			got = append(got, r.Name+" ("+string(r.RoleType)+")")
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.input, strings.Join(got, " "), tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Family is a group of notations for what is tactically the same shape, such
+// as 4-5-1, 4-2-3-1 and 4-4-1-1. Canonical is the notation that stands for
+// the whole family, so family-level data still parses as a formation.
+type Family struct {
+	Name        string
+	Canonical   string
+	Description string
+	match       func(lines []int) bool
+}
+
+// Families are the rule-based families, tried in order; the first whose rule
+// matches a formation's lines is its family. A back four is split by the
+// number of forwards in its last line alone, so the layering of the
+// midfield (4-2-3-1 or 4-4-1-1, 4-1-2-1-2 or 4-4-2) does not change the
+// family. Formations none of them match form a family of their own (see
+// FamilyOf).
+var Families = []Family{
+	{Name: "back three", Canonical: "3-4-3", Description: "three centre-backs, wing-backs in midfield",
+		match: func(l []int) bool { return l[0] == 3 }},
+	{Name: "back five", Canonical: "5-3-2", Description: "three centre-backs and two full-backs",
+		match: func(l []int) bool { return l[0] == 5 }},
+	{Name: "back four, front three", Canonical: "4-3-3", Description: "three forwards across the front",
+		match: func(l []int) bool { return l[0] == 4 && l[len(l)-1] == 3 }},
+	{Name: "back four, two up", Canonical: "4-4-2", Description: "two strikers, ahead of a flat or diamond midfield",
+		match: func(l []int) bool { return l[0] == 4 && l[len(l)-1] == 2 }},
+	{Name: "back four, lone striker", Canonical: "4-5-1", Description: "one striker ahead of five midfielders, in one line or layered",
+		match: func(l []int) bool { return l[0] == 4 && l[len(l)-1] == 1 }},
+}
+
+// FamilyOf returns the family of a formation in dashed notation. A formation
+// no rule matches is its own family, named and canonicalised by its
+// defence-midfield-attack shape.
+func FamilyOf(f string) (Family, error) {
+	lines, err := ParseLines(f)
+	if err != nil {
+		return Family{}, err
+	}
+	for _, fam := range Families {
+		if fam.match(lines) {
+			return fam, nil
+		}
+	}
+	roles, _ := ParseDashed(f)
+	shape := ShapeOf(roles)
+	return Family{Name: shape.String(), Canonical: shape.String(), Description: "no family rule matches"}, nil
+}
+
+// Canonical returns the canonical formation of f's family, or f itself when
+// it does not parse.
+func Canonical(f string) string {
+	fam, err := FamilyOf(f)
+	if err != nil {
+		return f
+	}
+	return fam.Canonical
+}
+
+// Cluster is a group of formations found by ClusterFormations.
+type Cluster struct {
+	Members []string // most frequent first
+	Medoid  string   // the member nearest the rest, weighted by frequency
+	Weight  int      // total frequency of the members
+}
+
+func (c Cluster) String() string {
+	return fmt.Sprintf("%s: %s", c.Medoid, strings.Join(c.Members, ", "))
+}
+
+// ClusterFormations groups formations by agglomerative clustering on
+// LineDistance with average linkage, weighted by how often each formation
+// occurs (weights, parallel to formations). Merging stops at k clusters or,
+// when k <= 0, once the nearest two clusters are further apart than
+// maxDistance. Clusters come heaviest first; formations that do not parse
+// are left out.
+func ClusterFormations(formations []string, weights []int, k int, maxDistance float64) []Cluster {
+	type item struct {
+		name   string
+		lines  []int
+		weight int
+	}
+	items := []item{}
+	for i, f := range formations {
+		lines, err := ParseLines(f)
+		if err != nil {
+			continue
+		}
+		items = append(items, item{f, lines, weights[i]})
+	}
+	dist := make([][]float64, len(items))
+	for i := range items {
+		dist[i] = make([]float64, len(items))
+		for j := range items {
+			dist[i][j] = LineDistance(items[i].lines, items[j].lines).Total
+		}
+	}
+
+	groups := make([][]int, len(items))
+	for i := range items {
+		groups[i] = []int{i}
+	}
+	linkage := func(a, b []int) float64 {
+		sum, w := 0.0, 0.0
+		for _, i := range a {
+			for _, j := range b {
+				ww := float64(items[i].weight * items[j].weight)
+				sum += ww * dist[i][j]
+				w += ww
+			}
+		}
+		if w == 0 {
+			return math.Inf(1)
+		}
+		return sum / w
+	}
+	for len(groups) > 1 && (k <= 0 || len(groups) > k) {
+		bi, bj, best := -1, -1, math.Inf(1)
+		for i := range groups {
+			for j := i + 1; j < len(groups); j++ {
+				if d := linkage(groups[i], groups[j]); d < best-1e-12 {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+		if bi < 0 || k <= 0 && best > maxDistance {
+			break
+		}
+		groups[bi] = append(groups[bi], groups[bj]...)
+		groups = append(groups[:bj], groups[bj+1:]...)
+	}
+
+	clusters := make([]Cluster, len(groups))
+	for g, members := range groups {
+		sort.SliceStable(members, func(a, b int) bool { return items[members[a]].weight > items[members[b]].weight })
+		c := Cluster{}
+		medoid, best := -1, math.Inf(1)
+		for _, i := range members {
+			c.Members = append(c.Members, items[i].name)
+			c.Weight += items[i].weight
+			spread := 0.0
+			for _, j := range members {
+				spread += float64(items[j].weight) * dist[i][j]
+			}
+			if spread < best-1e-12 {
+				medoid, best = i, spread
+			}
+		}
+		c.Medoid = items[medoid].name
+		clusters[g] = c
+	}
+	sort.SliceStable(clusters, func(a, b int) bool { return clusters[a].Weight > clusters[b].Weight })
+	return clusters
+}
@@ -0,0 +1,42 @@
+package formation
+
+import "testing"
+
+func TestFamilyOf(t *testing.T) {
+	tests := []struct {
+		formation string
+		family    string
+	}{
+		{"3-4-3", "back three"},
+		{"3-5-2", "back three"},
+		{"3-4-1-2", "back three"},
+		{"5-3-2", "back five"},
+		{"5-4-1", "back five"},
+		{"4-3-3", "back four, front three"},
+		{"4-1-2-3", "back four, front three"},
+		{"4-4-2", "back four, two up"},
+		{"4-1-2-1-2", "back four, two up"},
+		{"4-3-1-2", "back four, two up"},
+		{"4-2-2-2", "back four, two up"},
+		{"4-5-1", "back four, lone striker"},
+		{"4-2-3-1", "back four, lone striker"},
+		{"4-4-1-1", "back four, lone striker"},
+		{"4-1-4-1", "back four, lone striker"},
+		{"4-3-2-1", "back four, lone striker"},
+		{"4-6-0", "4-6-0"},
+		{"2-4-4", "2-4-4"},
+	}
+	for _, tt := range tests {
+		fam, err := FamilyOf(tt.formation)
+		if err != nil {
+			t.Errorf("FamilyOf(%s): %v", tt.formation, err)
+			continue
+		}
+		if fam.Name != tt.family {
+			t.Errorf("FamilyOf(%s) = %q, want %q", tt.formation, fam.Name, tt.family)
+		}
+	}
+	if _, err := FamilyOf("four-four-two"); err == nil {
+		t.Error("FamilyOf(four-four-two): no error")
+	}
+}
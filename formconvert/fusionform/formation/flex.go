@@ -0,0 +1,214 @@
+package formation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FlexPolicy decides what a Flex role plays. Resolve is given the Flex role
+// and the whole formation (earlier Flex roles already resolved) and returns
+// the concrete role type and a short reason.
+type FlexPolicy interface {
+	Name() string
+	Resolve(flex Role, formation []Role) (RoleType, string, error)
+}
+
+// FlexDecision records how one Flex role was resolved.
+type FlexDecision struct {
+	Flex   Role
+	As     RoleType
+	Policy string
+	Reason string
+}
+
+func (d FlexDecision) String() string {
+	return fmt.Sprintf("%s -> %s (%s): %s", d.Flex.Name, d.As, d.Policy, d.Reason)
+}
+
+// ResolveFlex turns every Flex role in roles into a concrete role chosen by
+// policy, keeping its name, and returns the decisions in order. A nil policy
+// leaves the roles as they are. Each decision is also added to trace.
+func ResolveFlex(roles []Role, policy FlexPolicy, trace *Trace) ([]Role, []FlexDecision, error) {
+	if policy == nil {
+		return roles, nil, nil
+	}
+	resolved := append([]Role{}, roles...)
+	decisions := []FlexDecision{}
+	for i, r := range resolved {
+		if r.RoleType != Flexible {
+			continue
+		}
+		as, reason, err := policy.Resolve(r, resolved)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving %s: %w", r.Name, err)
+		}
+		if as == Flexible {
+			return nil, nil, fmt.Errorf("resolving %s: policy %s kept it Flex", r.Name, policy.Name())
+		}
+		resolved[i] = Role{RoleType: as, Name: r.Name, Pos: r.Pos}
+		d := FlexDecision{Flex: r, As: as, Policy: policy.Name(), Reason: reason}
+		decisions = append(decisions, d)
+		trace.Add(Step{
+			Rule:   "Resolve " + r.Name + " (" + policy.Name() + ")",
+			Chosen: []Role{resolved[i]},
+			Reason: reason,
+		})
+	}
+	return resolved, decisions, nil
+}
+
+// FixedRole resolves every Flex to the same role.
+type FixedRole struct {
+	Role RoleType
+}
+
+func (p FixedRole) Name() string { return "role" }
+
+func (p FixedRole) Resolve(Role, []Role) (RoleType, string, error) {
+	return p.Role, "specified by the coach", nil
+}
+
+// MostNeeded resolves a Flex into the line furthest below its share of
+// Target, the earlier line in DefaultPriority on a tie. The role is the one
+// in that line Flex covers best, the line's central role if it covers none.
+type MostNeeded struct {
+	Target Shape
+	Compat Compatibility
+}
+
+func (p MostNeeded) Name() string { return "most-needed line" }
+
+func (p MostNeeded) Resolve(flex Role, formation []Role) (RoleType, string, error) {
+	total := p.Target[0] + p.Target[1] + p.Target[2]
+	if total == 0 {
+		return "", "", fmt.Errorf("empty target shape")
+	}
+	have := ShapeOf(formation)
+	n := float64(have[0] + have[1] + have[2] + 1) // the Flex joins a line
+	line, shortfall := -1, 0.0
+	for _, l := range DefaultPriority {
+		need := float64(p.Target[l])*n/float64(total) - float64(have[l])
+		if line < 0 || need > shortfall+1e-9 {
+			line, shortfall = l, need
+		}
+	}
+	return lineRole(line, p.Compat, formation), fmt.Sprintf("%s is %.1f short of a %s balance", lineNames[line], shortfall, p.Target), nil
+}
+
+// MirrorOpponent resolves a Flex into the line the opponent outnumbers most:
+// our defence against their attack, midfield against midfield, attack
+// against their defence.
+type MirrorOpponent struct {
+	Opponent Shape
+	Compat   Compatibility
+}
+
+func (p MirrorOpponent) Name() string { return "mirror opponent" }
+
+func (p MirrorOpponent) Resolve(flex Role, formation []Role) (RoleType, string, error) {
+	total := p.Opponent[0] + p.Opponent[1] + p.Opponent[2]
+	if total == 0 {
+		return "", "", fmt.Errorf("empty opponent shape")
+	}
+	have := ShapeOf(formation)
+	scale := float64(have[0]+have[1]+have[2]+1) / float64(total)
+	line, gap := -1, 0.0
+	for _, l := range DefaultPriority {
+		g := float64(p.Opponent[2-l])*scale - float64(have[l])
+		if line < 0 || g > gap+1e-9 {
+			line, gap = l, g
+		}
+	}
+	return lineRole(line, p.Compat, formation), fmt.Sprintf("their %s outnumbers our %s (opponent %s)", lineNames[2-line], lineNames[line], p.Opponent), nil
+}
+
+// lineRole is the role of a line Flex covers best. When it covers none of
+// them, it is the line's role the formation has fewest of, the central one
+// on a tie, so a back line of two CDs gains a full-back.
+func lineRole(line int, compat Compatibility, formation []Role) RoleType {
+	if compat == nil {
+		compat = DefaultCompatibility()
+	}
+	best, score := lineTypes[line][0], 0.0
+	for _, rt := range lineTypes[line] {
+		if s := compat.Score(Flexible, rt); s > score {
+			best, score = rt, s
+		}
+	}
+	if score > 0 {
+		return best
+	}
+	counts := Counts(formation)
+	for _, rt := range lineTypes[line] {
+		if counts[rt] < counts[best] {
+			best = rt
+		}
+	}
+	return best
+}
+
+// Prompt asks the coach for each Flex role.
+type Prompt struct {
+	In  *bufio.Reader
+	Out io.Writer
+}
+
+func (p Prompt) Name() string { return "coach" }
+
+func (p Prompt) Resolve(flex Role, formation []Role) (RoleType, string, error) {
+	for {
+		fmt.Fprintf(p.Out, "Formation so far: %s %s\n", ShapeOf(formation), listRoles(formation))
+		fmt.Fprintf(p.Out, "Role for %s (CD, FB, CM, WM, ST): ", flex.Name)
+		line, err := p.In.ReadString('\n')
+		rt, perr := ParseRoleType(strings.TrimSpace(line))
+		if perr == nil && rt != Flexible {
+			return rt, "chosen at the prompt", nil
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("no role given: %v", err)
+		}
+		fmt.Fprintln(p.Out, "Not a role:", strings.TrimSpace(line))
+	}
+}
+
+// ParseFlexPolicy reads a policy from the command line: "keep" (no
+// resolution, nil), "prompt", "needed", "mirror:4-3-3" or "role:WM". The
+// opponent of "mirror" may have four or five lines ("mirror:4-2-3-1"); those
+// between the first and the last count as midfield. Prompts
+// read from in and write to out; target is the shape "needed" balances
+// towards.
+func ParseFlexPolicy(spec string, target Shape, compat Compatibility, in *bufio.Reader, out io.Writer) (FlexPolicy, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch strings.ToLower(name) {
+	case "", "keep":
+		return nil, nil
+	case "prompt":
+		return Prompt{In: in, Out: out}, nil
+	case "needed":
+		return MostNeeded{Target: target, Compat: compat}, nil
+	case "mirror":
+		lines, err := ParseLines(arg)
+		if err != nil || len(lines) < 3 {
+			return nil, fmt.Errorf("mirror wants the opponent's lines, e.g. mirror:4-3-3 or mirror:4-2-3-1")
+		}
+		// Lines between the first and the last are all midfield, as in
+		// ParseDashed
+		opponent := Shape{lines[0], 0, lines[len(lines)-1]}
+		for _, n := range lines[1 : len(lines)-1] {
+			opponent[1] += n
+		}
+		if opponent == (Shape{}) {
+			return nil, fmt.Errorf("mirror wants an opponent with players, got %s", arg)
+		}
+		return MirrorOpponent{Opponent: opponent, Compat: compat}, nil
+	case "role":
+		rt, err := ParseRoleType(arg)
+		if err != nil || rt == Flexible {
+			return nil, fmt.Errorf("role wants a concrete role, e.g. role:WM")
+		}
+		return FixedRole{Role: rt}, nil
+	}
+	return nil, fmt.Errorf("unknown flex policy %q (want keep, prompt, needed, mirror:D-M-A or role:ROLE)", spec)
+}
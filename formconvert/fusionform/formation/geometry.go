@@ -0,0 +1,185 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point is a nominal position on a normalised pitch: X runs across from the
+// left touchline (0) to the right (1), Y up from our goal line (0) to the
+// opponent's (1). Being normalised, the same point sits on any size of pitch.
+type Point struct {
+	X, Y float64
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%.2f, %.2f)", p.X, p.Y)
+}
+
+// Pitch is the playing area of a format, in metres.
+type Pitch struct {
+	Name          string
+	Length, Width float64
+}
+
+// The pitch of each format, from the middle of the FA's recommended ranges.
+var (
+	Pitch11 = Pitch{Name: "11-a-side", Length: 105, Width: 68}
+	Pitch8  = Pitch{Name: "8-a-side", Length: 68, Width: 46}
+	Pitch7  = Pitch{Name: "7-a-side", Length: 55, Width: 37}
+)
+
+// PitchFor returns the pitch of the format with the given number of outfield
+// players, 11-a-side for anything it does not know.
+func PitchFor(outfield int) Pitch {
+	switch outfield {
+	case 6:
+		return Pitch7
+	case 7:
+		return Pitch8
+	}
+	return Pitch11
+}
+
+// Metres places a normalised point on the pitch.
+func (p Pitch) Metres(pt Point) (x, y float64) {
+	return pt.X * p.Width, pt.Y * p.Length
+}
+
+// Distance is how far apart two normalised points are on the pitch, in
+// metres.
+func (p Pitch) Distance(a, b Point) float64 {
+	ax, ay := p.Metres(a)
+	bx, by := p.Metres(b)
+	return math.Hypot(ax-bx, ay-by)
+}
+
+// lineDepth is how far up the pitch each line plays; a Flex sits in the
+// middle of the park.
+var lineDepth = [3]float64{0.2, 0.5, 0.78}
+
+// Place gives every role its nominal position and returns the roles. Each
+// line is spread evenly across the pitch with its wide roles on the
+// outside: the first full-back (or wide midfielder) on the left, the second
+// on the right, centre-backs (or central midfielders) between them.
+func Place(roles []Role) []Role {
+	for line, types := range lineTypes {
+		central := []int{}
+		wide := []int{}
+		for i, r := range roles {
+			switch {
+			case r.RoleType == types[0]:
+				central = append(central, i)
+			case len(types) > 1 && r.RoleType == types[1]:
+				wide = append(wide, i)
+			}
+		}
+		left := (len(wide) + 1) / 2
+		order := append(append(append([]int{}, wide[:left]...), central...), wide[left:]...)
+		for k, i := range order {
+			roles[i].Pos = Point{X: (float64(k) + 0.5) / float64(len(order)), Y: lineDepth[line]}
+		}
+	}
+	for i, r := range roles {
+		if r.RoleType == Flexible {
+			roles[i].Pos = Point{X: 0.5, Y: lineDepth[MidfieldLine]}
+		}
+	}
+	return roles
+}
+
+// placed reports whether any role has a position.
+func placed(roles []Role) bool {
+	for _, r := range roles {
+		if r.Pos != (Point{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertGeometric fills the target slots by where players stand instead of
+// by role counts. The source positions (nominal ones from Place when the
+// roles have none) are laid on the target pitch, the slots take their
+// nominal positions, and the assignment minimises the total distance in
+// metres between each player and their slot. Converting up, slots no player
+// reaches are filled by added players named after their slot. Each result
+// carries its slot's position.
+func ConvertGeometric(source []Role, target []RoleType, to Pitch, opts Options) ([]Role, error) {
+	if len(source) == 0 {
+		return nil, fmt.Errorf("no players to place")
+	}
+	from := append([]Role{}, source...)
+	if !placed(from) {
+		Place(from)
+	}
+	slots := Place(roleSlots(target))
+
+	// An added player costs more than any move on the pitch, so everyone in
+	// the source plays when there are places for them.
+	added := math.Hypot(to.Length, to.Width)
+	columns := max(len(from), len(slots))
+	cost := make([][]float64, len(slots))
+	for i, slot := range slots {
+		cost[i] = make([]float64, columns)
+		for j := range cost[i] {
+			if j < len(from) {
+				cost[i][j] = to.Distance(from[j].Pos, slot.Pos)
+			} else {
+				cost[i][j] = added
+			}
+		}
+	}
+	assigned := hungarian(cost)
+
+	roles := make([]Role, len(slots))
+	count := map[RoleType]int{}
+	for i, slot := range slots {
+		j := assigned[i]
+		name := ""
+		if j < len(from) {
+			name = from[j].Name
+		} else {
+			count[slot.RoleType]++
+			name = fmt.Sprintf("%s_Add_%d", slot.RoleType, count[slot.RoleType])
+		}
+		roles[i] = Role{RoleType: slot.RoleType, Name: name, Pos: slot.Pos}
+		if opts.Trace != nil {
+			reason := "no player near enough; added"
+			chosen := roles[i : i+1]
+			if j < len(from) {
+				reason = fmt.Sprintf("%s from %s, %.1f m away on the %s pitch", from[j].Name, from[j].Pos, cost[i][j], to.Name)
+				chosen = from[j : j+1]
+			}
+			opts.Trace.Add(Step{Rule: fmt.Sprintf("Slot %d: %s at %s", i+1, slot.RoleType, slot.Pos), Chosen: chosen, Reason: reason})
+		}
+	}
+	if opts.Trace != nil {
+		opts.Trace.Add(Step{Rule: "Dropped", Chosen: RemoveRoles(from, originals(from, roles)), Reason: "every slot has a closer player"})
+	}
+	return roles, nil
+}
+
+// Convert11to7Geometric is the geometric alternative to
+// Convert11to7Relational.
+func Convert11to7Geometric(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertGeometric(formation11, opts.style().Target7(), Pitch7, opts)
+}
+
+// Convert11to8Geometric is the geometric alternative to
+// Convert11to8Relational.
+func Convert11to8Geometric(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertGeometric(formation11, opts.style().Target8(), Pitch8, opts)
+}
+
+// Convert7to11Geometric is the geometric alternative to
+// Convert7to11RuleBased.
+func Convert7to11Geometric(formation7 []Role, opts Options) ([]Role, error) {
+	return ConvertGeometric(formation7, opts.style().Lines.Slots(), Pitch11, opts)
+}
+
+// Convert8to11Geometric is the geometric alternative to
+// Convert8to11Relational.
+func Convert8to11Geometric(formation8 []Role, opts Options) ([]Role, error) {
+	return ConvertGeometric(formation8, opts.style().Lines.Slots(), Pitch11, opts)
+}
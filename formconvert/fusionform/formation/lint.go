@@ -0,0 +1,255 @@
+package formation
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed lint.csv
+var defaultLintRules string
+
+// Severity is how serious a lint finding is.
+type Severity int
+
+const (
+	Off Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "off"
+}
+
+// ParseSeverity reads "error", "warning" or "off".
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range []Severity{Off, Warning, Error} {
+		if strings.EqualFold(strings.TrimSpace(s), sev.String()) {
+			return sev, nil
+		}
+	}
+	return Off, fmt.Errorf("unknown severity %q (want error, warning or off)", s)
+}
+
+// LintRule is one configured check. Format is the players a side it applies
+// to (11, 8 or 7), 0 for every format; a rule for the format itself wins over
+// one for all of them. Value is the rule's limit; HasValue is false when the
+// rule takes its default (the format's outfield count, for players).
+type LintRule struct {
+	Name     string
+	Format   int
+	Value    int
+	HasValue bool
+	Severity Severity
+}
+
+// Finding is a rule a formation breaks.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Rule, f.Message)
+}
+
+// LintConfig is the rules the linter checks, keyed by rule name and format.
+type LintConfig map[string]map[int]LintRule
+
+// lintChecks are the rules the linter knows. Each gets the formation and the
+// rule's value, and returns a message when the formation breaks the rule.
+var lintChecks = map[string]func(roles []Role, value int) string{
+	"players": func(roles []Role, value int) string {
+		if len(roles) != value {
+			return fmt.Sprintf("%d outfield players, want %d", len(roles), value)
+		}
+		return ""
+	},
+	"max-flex": func(roles []Role, value int) string {
+		if n := Counts(roles)[Flexible]; n > value {
+			return fmt.Sprintf("%d Flex roles, at most %d allowed", n, value)
+		}
+		return ""
+	},
+	"min-defenders": func(roles []Role, value int) string {
+		return atLeast(ShapeOf(roles)[DefenceLine], value, "defenders")
+	},
+	"min-midfielders": func(roles []Role, value int) string {
+		return atLeast(ShapeOf(roles)[MidfieldLine], value, "midfielders")
+	},
+	"min-attackers": func(roles []Role, value int) string {
+		return atLeast(ShapeOf(roles)[AttackLine], value, "strikers")
+	},
+	"min-fullbacks": func(roles []Role, value int) string {
+		return atLeast(Counts(roles)[FullBack], value, "full-backs")
+	},
+	"lateral-balance": func(roles []Role, value int) string {
+		for _, rt := range []RoleType{FullBack, WideMidfielder} {
+			left, right := flanks(FilterRoles(roles, []RoleType{rt}))
+			if left-right > value || right-left > value {
+				return fmt.Sprintf("%d %s on the left, %d on the right", left, rt, right)
+			}
+		}
+		return ""
+	},
+}
+
+func atLeast(n, min int, what string) string {
+	if n < min {
+		return fmt.Sprintf("%d %s, at least %d needed", n, what, min)
+	}
+	return ""
+}
+
+// flanks counts the roles left and right of the middle of the pitch. Roles
+// without positions are split as Place would put them: the first half (the
+// odd one included) on the left.
+func flanks(roles []Role) (left, right int) {
+	if !placed(roles) {
+		return (len(roles) + 1) / 2, len(roles) / 2
+	}
+	for _, r := range roles {
+		switch {
+		case r.Pos.X < 0.5-1e-9:
+			left++
+		case r.Pos.X > 0.5+1e-9:
+			right++
+		}
+	}
+	return left, right
+}
+
+// DefaultLintConfig returns the built-in rules.
+func DefaultLintConfig() LintConfig {
+	c := LintConfig{}
+	if err := c.Read(strings.NewReader(defaultLintRules)); err != nil {
+		panic("formation: invalid built-in lint rules: " + err.Error())
+	}
+	return c
+}
+
+// LoadLintConfig returns the built-in rules with those in a CSV file (see
+// Read) laid over them. An empty path gives the built-in rules.
+func LoadLintConfig(path string) (LintConfig, error) {
+	c := DefaultLintConfig()
+	if path == "" {
+		return c, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := c.Read(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Read sets rules from CSV with a "rule,format,value,severity" header. The
+// format is 11, 8, 7 or "all"; an empty value takes the rule's default.
+// Lines starting with # are comments.
+func (c LintConfig) Read(in io.Reader) error {
+	cr := csv.NewReader(in)
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	if strings.Join(header, ",") != "rule,format,value,severity" {
+		return fmt.Errorf("header must be \"rule,format,value,severity\"")
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		r := LintRule{Name: strings.TrimSpace(rec[0])}
+		if _, ok := lintChecks[r.Name]; !ok {
+			return fmt.Errorf("line %d: unknown rule %q", line, r.Name)
+		}
+		if f := strings.TrimSpace(rec[1]); f != "all" {
+			r.Format, err = strconv.Atoi(f)
+			if err != nil || r.Format != 11 && r.Format != 8 && r.Format != 7 {
+				return fmt.Errorf("line %d: format must be 11, 8, 7 or all, got %q", line, rec[1])
+			}
+		}
+		if v := strings.TrimSpace(rec[2]); v != "" {
+			r.Value, err = strconv.Atoi(v)
+			if err != nil || r.Value < 0 {
+				return fmt.Errorf("line %d: invalid value %q", line, rec[2])
+			}
+			r.HasValue = true
+		}
+		if r.Severity, err = ParseSeverity(rec[3]); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if c[r.Name] == nil {
+			c[r.Name] = map[int]LintRule{}
+		}
+		c[r.Name][r.Format] = r
+	}
+}
+
+// outfieldOf is the outfield count of a format given as players a side.
+func outfieldOf(format int) int {
+	switch format {
+	case 8:
+		return 7
+	case 7:
+		return 6
+	}
+	return 10
+}
+
+// Lint checks roles, a formation of the given format (players a side: 11, 8
+// or 7), against the rules and returns what it breaks, errors first, then by
+// rule name. The Flex_8_1 placeholder of a parsed 8-a-side formation is no
+// player and is left out (see WithoutFlex8).
+func (c LintConfig) Lint(roles []Role, format int) []Finding {
+	outfield := outfieldOf(format)
+	if format == 8 {
+		roles = WithoutFlex8(roles)
+	}
+	findings := []Finding{}
+	for name, byFormat := range c {
+		r, ok := byFormat[format]
+		if !ok {
+			r, ok = byFormat[0]
+		}
+		if !ok || r.Severity == Off {
+			continue
+		}
+		value := r.Value
+		if !r.HasValue && name == "players" {
+			value = outfield
+		}
+		if msg := lintChecks[name](roles, value); msg != "" {
+			findings = append(findings, Finding{Rule: name, Severity: r.Severity, Message: msg})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+	return findings
+}
@@ -0,0 +1,76 @@
+package formation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintCanonicalShapes(t *testing.T) {
+	c := DefaultLintConfig()
+	tests := []struct {
+		format int
+		parse  func(string) ([]Role, error)
+		inputs []string
+	}{
+		{11, ParseNumericalFormationInput11, []string{"442", "433", "451", "532", "diamond", "christmas tree"}},
+		{8, ParseNumericalFormationInput8, []string{"232", "331", "322", "8-a-side pyramid", "8-a-side diamond"}},
+		{7, ParseNumericalFormationInput7, []string{"231", "222", "321", "pyramid"}},
+	}
+	for _, tt := range tests {
+		for _, input := range tt.inputs {
+			roles, err := tt.parse(input)
+			if err != nil {
+				t.Errorf("%d-a-side %s: %v", tt.format, input, err)
+				continue
+			}
+			if findings := c.Lint(roles, tt.format); len(findings) > 0 {
+				t.Errorf("%d-a-side %s: %v, want no findings", tt.format, input, findings)
+			}
+		}
+	}
+}
+
+func TestLintFindings(t *testing.T) {
+	c := DefaultLintConfig()
+	roles, err := ParseNumericalFormationInput11("352")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := c.Lint(roles, 11)
+	if len(findings) != 1 || findings[0].Rule != "min-fullbacks" || findings[0].Severity != Warning {
+		t.Errorf("3-5-2: %v, want one min-fullbacks warning", findings)
+	}
+
+	short := roles[:9]
+	findings = c.Lint(short, 11)
+	if len(findings) == 0 || findings[0].Rule != "players" || findings[0].Severity != Error {
+		t.Errorf("nine players: %v, want a players error first", findings)
+	}
+
+	flex := append(append([]Role{}, roles[:8]...), Role{RoleType: Flexible, Name: "Flex_1"}, Role{RoleType: Flexible, Name: "Flex_2"})
+	found := false
+	for _, f := range c.Lint(flex, 11) {
+		found = found || f.Rule == "max-flex"
+	}
+	if !found {
+		t.Error("two Flex roles: no max-flex finding")
+	}
+}
+
+func TestLintConfigOverride(t *testing.T) {
+	c := DefaultLintConfig()
+	if err := c.Read(strings.NewReader("rule,format,value,severity\nmin-fullbacks,11,,off\nmin-attackers,11,2,error\n")); err != nil {
+		t.Fatal(err)
+	}
+	roles, err := ParseNumericalFormationInput11("451")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := c.Lint(roles, 11)
+	if len(findings) != 1 || findings[0].Rule != "min-attackers" || findings[0].Severity != Error {
+		t.Errorf("4-5-1 with min-attackers 2: %v, want one min-attackers error", findings)
+	}
+	if err := c.Read(strings.NewReader("rule,format,value,severity\nno-such-rule,all,,error\n")); err == nil {
+		t.Error("unknown rule: no error")
+	}
+}
@@ -0,0 +1,181 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// mirrorTolerance is how far, in metres on the formation's pitch, a role may
+// stand from the mirror image of another of its type and still count as its
+// counterpart on the other flank.
+const mirrorTolerance = 3.0
+
+// flankEdge is how far from the middle of the pitch (as a share of its width)
+// a player stands on a flank rather than centrally.
+const flankEdge = 0.3
+
+// sidedCodes are the sub-role codes that come in a left and a right version,
+// without the side letter: "B" for LB and RB, "CB" for LCB and RCB...
+var sidedCodes = map[string]bool{
+	"B": true, "WB": true, "CB": true, "CD": true, "DM": true, "CM": true, "M": true,
+	"AM": true, "W": true, "S": true, "H": true, "I": true,
+}
+
+// Mirror flips a formation left to right: every placed role moves to the
+// other flank, and sided names ("LWB_1", "RCB") swap their side letter.
+func Mirror(roles []Role) []Role {
+	mirrored := make([]Role, len(roles))
+	for i, r := range roles {
+		mirrored[i] = Role{RoleType: r.RoleType, Name: mirrorName(r.Name), Pos: r.Pos}
+		if r.Pos != (Point{}) {
+			mirrored[i].Pos.X = 1 - r.Pos.X
+		}
+	}
+	return mirrored
+}
+
+// mirrorName swaps the side letter of a sided name, leaving others alone.
+func mirrorName(name string) string {
+	code, rest, numbered := strings.Cut(name, "_")
+	if len(code) < 2 || !sidedCodes[code[1:]] {
+		return name
+	}
+	swapped := ""
+	switch code[0] {
+	case 'L':
+		swapped = "R" + code[1:]
+	case 'R':
+		swapped = "L" + code[1:]
+	default:
+		return name
+	}
+	if numbered {
+		return swapped + "_" + rest
+	}
+	return swapped
+}
+
+// Asymmetry lists the roles of a placed formation that have no mirror image
+// on the other flank: a role of the same type within mirrorTolerance of
+// where the role would stand mirrored. Roles are paired up by type at least
+// total distance, so a left wing-back pushed up against a right-back staying
+// home is reported for both. Distances are measured on pitch, the one the
+// formation's format plays on. A symmetric formation, or one not placed,
+// gives none.
+func Asymmetry(roles []Role, pitch Pitch) []string {
+	if !placed(roles) {
+		return nil
+	}
+	notes := []string{}
+	for _, rt := range RoleTypes {
+		group := FilterRoles(roles, []RoleType{rt})
+		if len(group) == 0 {
+			continue
+		}
+		mirrored := Mirror(group)
+		cost := make([][]float64, len(group))
+		for i := range group {
+			cost[i] = make([]float64, len(mirrored))
+			for j := range mirrored {
+				cost[i][j] = pitch.Distance(group[i].Pos, mirrored[j].Pos)
+			}
+		}
+		for i, j := range hungarian(cost) {
+			if cost[i][j] > mirrorTolerance {
+				notes = append(notes, fmt.Sprintf("%s (%s) at %s has no counterpart on the other flank (%.1f m off)", group[i].Name, rt, group[i].Pos, cost[i][j]))
+			}
+		}
+	}
+	return notes
+}
+
+// ConvertSided is ConvertGeometric for lopsided shapes. Players are assigned
+// to the same slots, but each keeps their own flank and how far they stand
+// off their line, instead of taking the slot's symmetric position, and their
+// role follows: a player put in a wide slot who stands centrally takes the
+// line's central role, and a wide player (full-back or wide midfielder) put
+// in a central slot on their flank takes the line's wide role. A left
+// wing-back with a right-back staying home therefore converts to a formation
+// with the same lean. Roles only change within a line whose wide role the
+// target uses, so a 7-a-side result never gains a full-back. Added players
+// take their slot as it is, and a symmetric source converts exactly as
+// ConvertGeometric, so it stays symmetric. from is the source's pitch.
+func ConvertSided(source []Role, from Pitch, target []RoleType, to Pitch, opts Options) ([]Role, error) {
+	placedSource := append([]Role{}, source...)
+	if !placed(placedSource) {
+		Place(placedSource)
+	}
+	if len(Asymmetry(placedSource, from)) == 0 {
+		opts.Trace.Add(Step{Rule: "Symmetric source", Reason: "no player to keep on their side; slots keep their symmetric places"})
+		return ConvertGeometric(source, target, to, opts)
+	}
+	roles, err := ConvertGeometric(source, target, to, Options{Compat: opts.Compat})
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]Role{}
+	for _, r := range placedSource {
+		byName[r.Name] = r
+	}
+	inTarget := map[RoleType]bool{}
+	for _, rt := range target {
+		inTarget[rt] = true
+	}
+
+	for i, r := range roles {
+		src, kept := byName[r.Name]
+		if !kept {
+			opts.Trace.Add(Step{Rule: fmt.Sprintf("Slot %d: %s", i+1, r.RoleType), Chosen: roles[i : i+1], Reason: "no player near enough; added at " + r.Pos.String()})
+			continue
+		}
+		offset := 0.0
+		if l := lineOf(src.RoleType); l >= 0 {
+			offset = src.Pos.Y - lineDepth[l]
+		}
+		roles[i].Pos = Point{X: src.Pos.X, Y: r.Pos.Y + offset}
+		reason := fmt.Sprintf("%s keeps their place across the pitch, at %s", src.Name, roles[i].Pos)
+
+		if line := lineOf(r.RoleType); line >= 0 && len(lineTypes[line]) > 1 && inTarget[lineTypes[line][1]] {
+			central, wide := lineTypes[line][0], lineTypes[line][1]
+			onFlank := math.Abs(src.Pos.X-0.5) > flankEdge
+			wideSource := src.RoleType == FullBack || src.RoleType == WideMidfielder
+			switch {
+			case r.RoleType == wide && !onFlank:
+				roles[i].RoleType = central
+				reason += fmt.Sprintf("; plays %s, standing centrally", central)
+			case r.RoleType == central && onFlank && wideSource:
+				roles[i].RoleType = wide
+				reason += fmt.Sprintf("; plays %s on their flank", wide)
+			}
+		}
+		opts.Trace.Add(Step{Rule: fmt.Sprintf("Slot %d: %s", i+1, r.RoleType), Chosen: roles[i : i+1], Reason: reason})
+	}
+	if opts.Trace != nil {
+		opts.Trace.Add(Step{Rule: "Dropped", Chosen: RemoveRoles(placedSource, originals(placedSource, roles)), Reason: "every slot has a closer player"})
+		if notes := Asymmetry(roles, to); len(notes) > 0 {
+			opts.Trace.Add(Step{Rule: "Asymmetry kept", Reason: strings.Join(notes, "; ")})
+		}
+	}
+	return roles, nil
+}
+
+// Convert11to7Sided is the side-keeping alternative to Convert11to7Geometric.
+func Convert11to7Sided(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertSided(formation11, Pitch11, opts.style().Target7(), Pitch7, opts)
+}
+
+// Convert11to8Sided is the side-keeping alternative to Convert11to8Geometric.
+func Convert11to8Sided(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertSided(formation11, Pitch11, opts.style().Target8(), Pitch8, opts)
+}
+
+// Convert7to11Sided is the side-keeping alternative to Convert7to11Geometric.
+func Convert7to11Sided(formation7 []Role, opts Options) ([]Role, error) {
+	return ConvertSided(formation7, Pitch7, opts.style().Lines.Slots(), Pitch11, opts)
+}
+
+// Convert8to11Sided is the side-keeping alternative to Convert8to11Geometric.
+func Convert8to11Sided(formation8 []Role, opts Options) ([]Role, error) {
+	return ConvertSided(formation8, Pitch8, opts.style().Lines.Slots(), Pitch11, opts)
+}
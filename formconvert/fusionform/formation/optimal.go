@@ -0,0 +1,150 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+)
+
+// uncoveredCost is the cost of a player taking a slot the compatibility
+// matrix says they cannot cover. It is finite so a formation short of cover
+// still converts, but larger than any covered move.
+const uncoveredCost = 10
+
+// Cost is the tactical cost of a player in role from taking a slot of role
+// to: 1 minus the compatibility score, so a natural position costs 0.
+func (c Compatibility) Cost(from, to RoleType) float64 {
+	score := c.Score(from, to)
+	if score <= 0 {
+		return uncoveredCost
+	}
+	return 1 - score
+}
+
+// ConvertOptimal fills the target slots with roles from the source formation
+// so that the total cost is as small as possible, instead of taking the
+// first match line by line. Costs come from compat (see Compatibility.Cost).
+// Each result keeps the source role's name and position and takes the slot's
+// role type, so an FB moved into the back line reads "FB_1 (CD)". Source
+// roles left over are dropped.
+func ConvertOptimal(source []Role, target []RoleType, opts Options) ([]Role, error) {
+	compat := opts.compat()
+	if len(target) > len(source) {
+		return nil, fmt.Errorf("cannot fill %d slots from %d roles", len(target), len(source))
+	}
+	cost := make([][]float64, len(target))
+	for i, slot := range target {
+		cost[i] = make([]float64, len(source))
+		for j, role := range source {
+			cost[i][j] = compat.Cost(role.RoleType, slot)
+		}
+	}
+	assigned := hungarian(cost)
+	roles := make([]Role, len(target))
+	total := 0.0
+	for i, slot := range target {
+		from := source[assigned[i]]
+		roles[i] = Role{RoleType: slot, Name: from.Name, Pos: from.Pos}
+		total += cost[i][assigned[i]]
+		if opts.Trace != nil {
+			reason := fmt.Sprintf("natural %s, cost 0", slot)
+			if from.RoleType != slot {
+				reason = fmt.Sprintf("%s covers %s (%.2g), cost %.2g", from.RoleType, slot, compat.Score(from.RoleType, slot), cost[i][assigned[i]])
+			}
+			opts.Trace.Add(Step{
+				Rule:       fmt.Sprintf("Slot %d: %s", i+1, slot),
+				Candidates: covering(source, slot, compat),
+				Chosen:     []Role{from},
+				Reason:     reason,
+			})
+		}
+	}
+	if opts.Trace != nil {
+		opts.Trace.Add(Step{
+			Rule:   "Dropped",
+			Chosen: RemoveRoles(source, assignedRoles(source, assigned)),
+			Reason: fmt.Sprintf("the assignment above has the lowest total cost (%.2g)", total),
+		})
+	}
+	return roles, nil
+}
+
+// Convert11to7Optimal is the optimal-assignment alternative to
+// Convert11to7Relational.
+func Convert11to7Optimal(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertOptimal(formation11, opts.style().Target7(), opts)
+}
+
+// Convert11to8Optimal is the optimal-assignment alternative to
+// Convert11to8Relational.
+func Convert11to8Optimal(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertOptimal(formation11, opts.style().Target8(), opts)
+}
+
+func assignedRoles(source []Role, assigned []int) []Role {
+	roles := make([]Role, len(assigned))
+	for i, j := range assigned {
+		roles[i] = source[j]
+	}
+	return roles
+}
+
+// hungarian solves the rectangular assignment problem for an n×m cost matrix
+// with n <= m, returning the column assigned to each row. It is the
+// O(n²m) potentials formulation of the Hungarian algorithm.
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+	// Rows and columns are 1-based below; column 0 is a virtual start.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1) // p[j] is the row matched to column j
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for p[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+	assigned := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			assigned[p[j]-1] = j - 1
+		}
+	}
+	return assigned
+}
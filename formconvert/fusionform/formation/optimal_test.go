@@ -0,0 +1,73 @@
+package formation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// bruteForce returns the least total cost of assigning each row of cost a
+// distinct column, trying every injection.
+func bruteForce(cost [][]float64) float64 {
+	used := make([]bool, len(cost[0]))
+	var walk func(row int) float64
+	walk = func(row int) float64 {
+		if row == len(cost) {
+			return 0
+		}
+		best := math.Inf(1)
+		for j := range used {
+			if !used[j] {
+				used[j] = true
+				best = min(best, cost[row][j]+walk(row+1))
+				used[j] = false
+			}
+		}
+		return best
+	}
+	return walk(0)
+}
+
+func TestHungarianMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 500; trial++ {
+		n := 1 + rng.Intn(5)
+		m := n + rng.Intn(3)
+		cost := make([][]float64, n)
+		for i := range cost {
+			cost[i] = make([]float64, m)
+			for j := range cost[i] {
+				if trial%2 == 0 {
+					// Few distinct values, so ties are common as with the
+					// compatibility costs
+					cost[i][j] = []float64{0, 0.2, 0.3, uncoveredCost}[rng.Intn(4)]
+				} else {
+					cost[i][j] = rng.Float64() * 10
+				}
+			}
+		}
+
+		assigned := hungarian(cost)
+		if len(assigned) != n {
+			t.Fatalf("%v: got %d assignments, want %d", cost, len(assigned), n)
+		}
+		seen := map[int]bool{}
+		total := 0.0
+		for i, j := range assigned {
+			if j < 0 || j >= m || seen[j] {
+				t.Fatalf("%v: assignment %v is not one distinct column per row", cost, assigned)
+			}
+			seen[j] = true
+			total += cost[i][j]
+		}
+		if want := bruteForce(cost); math.Abs(total-want) > 1e-9 {
+			t.Errorf("%v: assignment %v costs %g, brute force finds %g", cost, assigned, total, want)
+		}
+	}
+}
+
+func TestHungarianEmpty(t *testing.T) {
+	if got := hungarian(nil); got != nil {
+		t.Errorf("hungarian(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,296 @@
+package formation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseDigits reads a 3-digit numerical formation ("442") into defender,
+// midfielder and attacker counts.
+func parseDigits(input, example string) (defenders, midfielders, attackers int, err error) {
+	if len(input) != 3 {
+		return 0, 0, 0, fmt.Errorf("input must be a 3-digit number string %s", example)
+	}
+
+	defenders, err = strconv.Atoi(string(input[0]))
+	if err != nil || defenders < 0 {
+		return 0, 0, 0, fmt.Errorf("invalid number of defenders")
+	}
+	midfielders, err = strconv.Atoi(string(input[1]))
+	if err != nil || midfielders < 0 {
+		return 0, 0, 0, fmt.Errorf("invalid number of midfielders")
+	}
+	attackers, err = strconv.Atoi(string(input[2]))
+	if err != nil || attackers < 0 {
+		return 0, 0, 0, fmt.Errorf("invalid number of attackers")
+	}
+	return defenders, midfielders, attackers, nil
+}
+
+// Function to parse numerical formation input string to []Role (e.g., "442").
+// A template name ("diamond", "christmas tree") gives the template's roles,
+// a position list ("GK,FB,CD,CD,FB,DM,CM,AM,W,W,ST") exactly those roles.
+func ParseNumericalFormationInput11(input string) ([]Role, error) {
+	if roles, ok, err := templateRoles(input, 10, "11-a-side"); ok {
+		return roles, err
+	}
+	if isPositionList(input) {
+		return ParsePositions(input, 10, "11-a-side")
+	}
+	if err := unknownTemplate(input); err != nil {
+		return nil, err
+	}
+	defenders, midfielders, attackers, err := parseDigits(input, "(e.g., 442)")
+	if err != nil {
+		return nil, err
+	}
+
+	totalOutfield := defenders + midfielders + attackers
+	if totalOutfield != 10 {
+		return nil, fmt.Errorf("total outfield players must be 10 for 11-a-side, got %d", totalOutfield)
+	}
+
+	return RolesFromLines(defenders, midfielders, attackers), nil
+}
+
+// RolesFromLines builds an 11-a-side role list from defender, midfielder and
+// attacker counts (4 defenders are 2 CD + 2 FB, 4 midfielders 2 CM + 2 WM...).
+func RolesFromLines(defenders, midfielders, attackers int) []Role {
+	formation := make([]Role, 0, defenders+midfielders+attackers)
+
+	// Assume distribution of roles within lines (can be customized)
+	// Defenders: Prioritize CD then FB
+	numCD := defenders
+	numFB := 0
+	if defenders == 4 {
+		numCD = 2
+		numFB = 2
+	} else if defenders == 3 {
+		numCD = 3
+		numFB = 0
+	} else if defenders == 5 { // Example for 5 defenders
+		numCD = 3
+		numFB = 2
+	}
+
+	for i := 0; i < numCD; i++ {
+		formation = append(formation, Role{RoleType: CenterBack, Name: fmt.Sprintf("CD_%d", i+1)})
+	}
+	for i := 0; i < numFB; i++ {
+		formation = append(formation, Role{RoleType: FullBack, Name: fmt.Sprintf("FB_%d", i+1)})
+	}
+
+	// Midfielders: Prioritize CM then WM
+	numCM := midfielders
+	numWM := 0
+	if midfielders == 4 {
+		numCM = 2
+		numWM = 2
+	} else if midfielders == 5 { // Example for 5 midfielders
+		numCM = 3
+		numWM = 2
+	} else if midfielders == 3 {
+		numCM = 3
+		numWM = 0
+	}
+
+	for i := 0; i < numCM; i++ {
+		formation = append(formation, Role{RoleType: CentralMidfielder, Name: fmt.Sprintf("CM_%d", i+1)})
+	}
+	for i := 0; i < numWM; i++ {
+		formation = append(formation, Role{RoleType: WideMidfielder, Name: fmt.Sprintf("WM_%d", i+1)})
+	}
+
+	// Attackers: Assume all are Strikers
+	for i := 0; i < attackers; i++ {
+		formation = append(formation, Role{RoleType: Striker, Name: fmt.Sprintf("ST_%d", i+1)})
+	}
+
+	return Place(formation)
+}
+
+// Function to parse numerical formation input string for 7-a-side (e.g., "231"),
+// a 7-a-side template name ("pyramid") or a position list ("CD,CD,WM,CM,WM,ST")
+func ParseNumericalFormationInput7(input string) ([]Role, error) {
+	if roles, ok, err := templateRoles(input, 6, "7-a-side"); ok {
+		return roles, err
+	}
+	if isPositionList(input) {
+		return ParsePositions(input, 6, "7-a-side")
+	}
+	if err := unknownTemplate(input); err != nil {
+		return nil, err
+	}
+	defenders, midfielders, attackers, err := parseDigits(input, "(e.g., 231)")
+	if err != nil {
+		return nil, err
+	}
+
+	totalOutfield := defenders + midfielders + attackers
+	if totalOutfield > 6 { // Allow max 6 outfield players making it seem like it didn't print.
+		return nil, fmt.Errorf("total outfield players must be at most 6 for 7-a-side, got %d", totalOutfield)
+	}
+
+	formation := make([]Role, 0, 6) // Max 6 outfield for 7-a-side
+
+	// Assume distribution of roles within lines (can be customized)
+	// Defenders: Assume all are Center Backs in 7-a-side numerical input
+	for i := 0; i < defenders; i++ {
+		formation = append(formation, Role{RoleType: CenterBack, Name: fmt.Sprintf("CD_%d", i+1)})
+	}
+
+	// Midfielders: Assume all are Central Midfielders in 7-a-side numerical input
+	for i := 0; i < midfielders; i++ {
+		formation = append(formation, Role{RoleType: CentralMidfielder, Name: fmt.Sprintf("CM_%d", i+1)})
+	}
+
+	// Attackers: Assume all are Strikers in 7-a-side numerical input
+	for i := 0; i < attackers; i++ {
+		formation = append(formation, Role{RoleType: Striker, Name: fmt.Sprintf("ST_%d", i+1)})
+	}
+
+	// Add Flexible Role if needed to reach 6 outfield (example: if input was like "230" -> 2-3-0 + 1 Flex = 2-3-1)
+	if totalOutfield < 6 {
+		formation = append(formation, Role{RoleType: Flexible, Name: "Flex_1"})
+	}
+
+	return Place(formation), nil
+}
+
+// Function to parse numerical formation input string for 8-a-side (e.g., "232"),
+// an 8-a-side template name ("8-a-side diamond") or a position list
+func ParseNumericalFormationInput8(input string) ([]Role, error) {
+	if roles, ok, err := templateRoles(input, 7, "8-a-side"); ok {
+		return roles, err
+	}
+	if isPositionList(input) {
+		return ParsePositions(input, 7, "8-a-side")
+	}
+	if err := unknownTemplate(input); err != nil {
+		return nil, err
+	}
+	defenders, midfielders, attackers, err := parseDigits(input, "for 8-a-side (e.g., 232)")
+	if err != nil {
+		return nil, err
+	}
+
+	totalOutfield := defenders + midfielders + attackers
+	if totalOutfield > 7 { // Max 7 outfield in 8-a-side formation input (excluding GK and flexible for now in num input)
+		return nil, fmt.Errorf("total outfield players should not exceed 7 for 8-a-side input, got %d", totalOutfield)
+	}
+
+	formation := make([]Role, 0, totalOutfield)
+
+	// Defenders: Assume all are Center Backs initially for simplicity in 8-a-side input
+	for i := 0; i < defenders; i++ {
+		formation = append(formation, Role{RoleType: CenterBack, Name: fmt.Sprintf("CD_8_%d", i+1)}) // CD_8 to differentiate from 11-a-side roles if needed
+	}
+
+	// Midfielders: Assume all are Central Midfielders initially
+	for i := 0; i < midfielders; i++ {
+		formation = append(formation, Role{RoleType: CentralMidfielder, Name: fmt.Sprintf("CM_8_%d", i+1)})
+	}
+
+	// Attackers: Assume all are Strikers
+	for i := 0; i < attackers; i++ {
+		formation = append(formation, Role{RoleType: Striker, Name: fmt.Sprintf("ST_8_%d", i+1)})
+	}
+
+	// Add 1 Flexible Role -  8-a-side often has 7 outfield + 1 flexible or specific 8th player (GK)
+	formation = append(formation, Role{RoleType: Flexible, Name: "Flex_8_1"}) // Adding a default flexible role for 8-a-side input
+
+	return Place(formation), nil
+}
+
+// WithoutFlex8 returns an 8-a-side formation without the Flex_8_1 role
+// ParseNumericalFormationInput8 appends to line counts, leaving only the
+// players the counts name.
+func WithoutFlex8(roles []Role) []Role {
+	out := []Role{}
+	for _, r := range roles {
+		if r.RoleType != Flexible || r.Name != "Flex_8_1" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ParseLines parses the dashed notation used in the datasets ("4-2-3-1") into
+// per-line player counts, back to front.
+func ParseLines(s string) ([]int, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("formation %q must have at least two lines (e.g., 4-4-2)", s)
+	}
+	lines := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid line %q in formation %q", p, s)
+		}
+		lines[i] = n
+	}
+	return lines, nil
+}
+
+// ParseDashed turns a dataset formation such as "4-2-3-1" into roles. The
+// first line are defenders, the last line attackers and every line between
+// counts as midfield, so 4-2-3-1 is read as 4-5-1. An 11-a-side template name
+// gives the template's roles.
+func ParseDashed(s string) ([]Role, error) {
+	if roles, ok, err := templateRoles(s, 10, "11-a-side"); ok {
+		return roles, err
+	}
+	if err := unknownTemplate(s); err != nil {
+		return nil, err
+	}
+	lines, err := ParseLines(s)
+	if err != nil {
+		return nil, err
+	}
+	midfielders := 0
+	for _, n := range lines[1 : len(lines)-1] {
+		midfielders += n
+	}
+	return RolesFromLines(lines[0], midfielders, lines[len(lines)-1]), nil
+}
+
+// LineRoles gives the role of every position in a formation's lines, in the
+// order each line is listed, matching the role counts of ParseDashed: the
+// ends of a back four or five are full-backs, and when the midfield holds
+// four or five players the ends of its widest line (the most advanced on a
+// tie) are wide midfielders.
+func LineRoles(lines []int) [][]RoleType {
+	roles := make([][]RoleType, len(lines))
+	last := len(lines) - 1
+	for i, n := range lines {
+		roles[i] = make([]RoleType, n)
+		for j := range roles[i] {
+			switch i {
+			case 0:
+				roles[i][j] = CenterBack
+				if (n == 4 || n == 5) && (j == 0 || j == n-1) {
+					roles[i][j] = FullBack
+				}
+			case last:
+				roles[i][j] = Striker
+			default:
+				roles[i][j] = CentralMidfielder
+			}
+		}
+	}
+
+	midfielders, widest := 0, 0
+	for i := 1; i < last; i++ {
+		midfielders += lines[i]
+		if lines[i] >= lines[widest] || widest == 0 {
+			widest = i
+		}
+	}
+	if (midfielders == 4 || midfielders == 5) && widest > 0 && lines[widest] >= 2 {
+		roles[widest][0] = WideMidfielder
+		roles[widest][lines[widest]-1] = WideMidfielder
+	}
+	return roles
+}
@@ -0,0 +1,198 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// partnershipWeight is how much a kept partnership's strength is worth
+// against the cost of moving players out of position (see Compatibility.Cost).
+const partnershipWeight = 1
+
+// Partnership links two roles of a formation that play as a unit.
+type Partnership struct {
+	A, B     string // role names
+	Kind     string
+	Strength float64 // in (0, 1]; how much the unit loses when split
+}
+
+func (p Partnership) String() string {
+	return fmt.Sprintf("%s–%s (%s, %.1f)", p.A, p.B, p.Kind, p.Strength)
+}
+
+// Graph is the relationship graph of a formation, one edge per partnership.
+type Graph []Partnership
+
+// Partnerships builds the graph of a role list from the units the logic
+// docs name: neighbouring centre-backs, the full-back and wide midfielder on
+// each flank, neighbouring strikers and neighbouring central midfielders.
+// Roles of a type are taken left to right in list order, so FB_1 and WM_1
+// share a flank.
+func Partnerships(roles []Role) Graph {
+	byType := map[RoleType][]string{}
+	for _, r := range roles {
+		byType[r.RoleType] = append(byType[r.RoleType], r.Name)
+	}
+	g := Graph{}
+	chain := func(names []string, kind string, strength float64) {
+		for i := 1; i < len(names); i++ {
+			g = append(g, Partnership{A: names[i-1], B: names[i], Kind: kind, Strength: strength})
+		}
+	}
+	chain(byType[CenterBack], "centre-back pair", 0.9)
+	fbs, wms := byType[FullBack], byType[WideMidfielder]
+	for i := 0; i < min(len(fbs), len(wms)); i++ {
+		g = append(g, Partnership{A: fbs[i], B: wms[i], Kind: "flank", Strength: 0.8})
+	}
+	chain(byType[Striker], "strike partnership", 0.8)
+	chain(byType[CentralMidfielder], "midfield pivot", 0.6)
+	return g
+}
+
+// Split returns the partnerships of g that do not survive in roles: those
+// with one or both players left out.
+func (g Graph) Split(roles []Role) Graph {
+	kept := map[string]bool{}
+	for _, r := range roles {
+		kept[r.Name] = true
+	}
+	split := Graph{}
+	for _, p := range g {
+		if !kept[p.A] || !kept[p.B] {
+			split = append(split, p)
+		}
+	}
+	return split
+}
+
+// Strength sums the strength of the partnerships among the named roles.
+func (g Graph) Strength(kept map[string]bool) float64 {
+	total := 0.0
+	for _, p := range g {
+		if kept[p.A] && kept[p.B] {
+			total += p.Strength
+		}
+	}
+	return total
+}
+
+func (g Graph) String() string {
+	if len(g) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(g))
+	for i, p := range g {
+		parts[i] = p.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ConvertPartnership fills the target slots like ConvertOptimal but chooses
+// which players stay so as to keep strong partnerships together: every
+// selection of players is scored by its assignment cost less the strength of
+// the partnerships it keeps whole, and the lowest wins. The graph is
+// opts.Partnerships, or Partnerships(source) when that is nil. Split
+// partnerships are recorded in the trace; Graph.Split reports them too.
+func ConvertPartnership(source []Role, target []RoleType, opts Options) ([]Role, error) {
+	if len(target) > len(source) {
+		return nil, fmt.Errorf("cannot fill %d slots from %d roles", len(target), len(source))
+	}
+	compat, graph := opts.compat(), opts.Partnerships
+	if graph == nil {
+		graph = Partnerships(source)
+	}
+
+	best := math.Inf(1)
+	var bestRoles []Role
+	subset := make([]int, len(target))
+	var walk func(start, depth int)
+	walk = func(start, depth int) {
+		if depth < len(target) {
+			for j := start; j <= len(source)-(len(target)-depth); j++ {
+				subset[depth] = j
+				walk(j+1, depth+1)
+			}
+			return
+		}
+		cost := make([][]float64, len(target))
+		for i, slot := range target {
+			cost[i] = make([]float64, len(subset))
+			for k, j := range subset {
+				cost[i][k] = compat.Cost(source[j].RoleType, slot)
+			}
+		}
+		assigned := hungarian(cost)
+		kept := map[string]bool{}
+		score := 0.0
+		for i, k := range assigned {
+			score += cost[i][k]
+			kept[source[subset[k]].Name] = true
+		}
+		score -= partnershipWeight * graph.Strength(kept)
+		if score < best-1e-9 {
+			best = score
+			bestRoles = make([]Role, len(target))
+			for i, k := range assigned {
+				from := source[subset[k]]
+				bestRoles[i] = Role{RoleType: target[i], Name: from.Name, Pos: from.Pos}
+			}
+		}
+	}
+	walk(0, 0)
+
+	if opts.Trace != nil {
+		split := graph.Split(bestRoles)
+		kept := Graph{}
+		for _, p := range graph {
+			if !contains(split, p) {
+				kept = append(kept, p)
+			}
+		}
+		opts.Trace.Add(Step{Rule: "Partnerships kept", Reason: kept.String()})
+		opts.Trace.Add(Step{Rule: "Partnerships broken", Reason: split.String()})
+		opts.Trace.Add(Step{
+			Rule:   "Assignment",
+			Chosen: bestRoles,
+			Reason: fmt.Sprintf("lowest cost less kept partnership strength (%.2f)", best),
+		})
+		opts.Trace.Add(Step{Rule: "Dropped", Chosen: RemoveRoles(source, originals(source, bestRoles)), Reason: "no slot left for these roles"})
+	}
+	return bestRoles, nil
+}
+
+func contains(g Graph, p Partnership) bool {
+	for _, q := range g {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// originals returns the source roles whose names appear in converted.
+func originals(source, converted []Role) []Role {
+	names := map[string]bool{}
+	for _, r := range converted {
+		names[r.Name] = true
+	}
+	out := []Role{}
+	for _, r := range source {
+		if names[r.Name] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Convert11to7Partnership is the partnership-preserving alternative to
+// Convert11to7Relational.
+func Convert11to7Partnership(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertPartnership(formation11, opts.style().Target7(), opts)
+}
+
+// Convert11to8Partnership is the partnership-preserving alternative to
+// Convert11to8Relational.
+func Convert11to8Partnership(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertPartnership(formation11, opts.style().Target8(), opts)
+}
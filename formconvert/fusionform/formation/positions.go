@@ -0,0 +1,128 @@
+package formation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Goalkeeper is the position code of the goalkeeper, who may head a position
+// list but has no role in the converters.
+const Goalkeeper = "GK"
+
+// Position is a position code a coach may write in a position list: the role
+// type it plays, how far up the pitch it stands and, for codes naming a side
+// ("LB", "RWB"), where across it.
+type Position struct {
+	RoleType RoleType
+	Depth    float64 // as Point.Y
+	X        float64 // as Point.X; 0 places the player by list order
+}
+
+// Positions are the codes accepted in a position list besides the role type
+// codes themselves (CD, FB, CM, WM, ST, Flex).
+var Positions = map[string]Position{
+	"CB": {CenterBack, lineDepth[DefenceLine], 0},
+	"WB": {FullBack, 0.3, 0},
+	"DM": {CentralMidfielder, 0.38, 0},
+	"AM": {CentralMidfielder, 0.62, 0},
+	"W":  {WideMidfielder, 0.66, 0},
+	"CF": {Striker, lineDepth[AttackLine], 0},
+	"F":  {Striker, lineDepth[AttackLine], 0},
+
+	// Sided codes keep their flank wherever they are listed, so lopsided
+	// shapes ("LWB" with an "RB" staying home) survive parsing.
+	"LB":  {FullBack, lineDepth[DefenceLine], 0.1},
+	"RB":  {FullBack, lineDepth[DefenceLine], 0.9},
+	"LWB": {FullBack, 0.3, 0.08},
+	"RWB": {FullBack, 0.3, 0.92},
+	"LCB": {CenterBack, lineDepth[DefenceLine], 0.35},
+	"RCB": {CenterBack, lineDepth[DefenceLine], 0.65},
+	"LCM": {CentralMidfielder, lineDepth[MidfieldLine], 0.35},
+	"RCM": {CentralMidfielder, lineDepth[MidfieldLine], 0.65},
+	"LM":  {WideMidfielder, lineDepth[MidfieldLine], 0.1},
+	"RM":  {WideMidfielder, lineDepth[MidfieldLine], 0.9},
+	"LW":  {WideMidfielder, 0.66, 0.1},
+	"RW":  {WideMidfielder, 0.66, 0.9},
+	"LS":  {Striker, lineDepth[AttackLine], 0.35},
+	"RS":  {Striker, lineDepth[AttackLine], 0.65},
+}
+
+// positionOf looks a code up in Positions, then among the role types, which
+// stand at their line's depth (a Flex in midfield).
+func positionOf(code string) (Position, bool) {
+	if p, ok := Positions[strings.ToUpper(code)]; ok {
+		return p, true
+	}
+	rt, err := ParseRoleType(code)
+	if err != nil {
+		return Position{}, false
+	}
+	if rt == Flexible {
+		return Position{rt, lineDepth[MidfieldLine], 0}, true
+	}
+	return Position{rt, lineDepth[lineOf(rt)], 0}, true
+}
+
+// isPositionList reports whether input is a position list rather than digits
+// or a template name.
+func isPositionList(input string) bool {
+	return strings.Contains(input, ",")
+}
+
+// ParsePositions reads a position list such as
+// "GK,FB,CD,CD,FB,DM,CM,AM,W,W,ST": one code per player, comma separated,
+// each a role type or one of Positions. The goalkeeper may be listed or left
+// out; either way the outfield must number exactly outfield players. Players
+// are named after their code and numbered ("CD_1", "CD_2"). Players at the
+// same depth are placed left to right in the order they are listed, so
+// "FB,CD,CD,FB" puts a full-back on each flank; sided codes keep their own
+// flank.
+func ParsePositions(input string, outfield int, format string) ([]Role, error) {
+	codes := strings.Split(input, ",")
+	type entry struct {
+		code string
+		pos  Position
+	}
+	entries := []entry{}
+	keepers := 0
+	for _, c := range codes {
+		c = strings.TrimSpace(c)
+		if strings.EqualFold(c, Goalkeeper) {
+			keepers++
+			continue
+		}
+		p, ok := positionOf(c)
+		if !ok {
+			return nil, fmt.Errorf("unknown position %q", c)
+		}
+		entries = append(entries, entry{strings.ToUpper(c), p})
+	}
+	if keepers > 1 {
+		return nil, fmt.Errorf("position list has %d goalkeepers", keepers)
+	}
+	if len(entries) != outfield {
+		return nil, fmt.Errorf("%s needs %d outfield players (%d with the goalkeeper), got %d", format, outfield, outfield+1, len(entries))
+	}
+
+	roles := make([]Role, len(entries))
+	seen := map[string]int{}
+	byDepth := map[float64][]int{}
+	for i, e := range entries {
+		if e.pos.RoleType == Flexible {
+			e.code = string(Flexible)
+		}
+		seen[e.code]++
+		roles[i] = Role{RoleType: e.pos.RoleType, Name: fmt.Sprintf("%s_%d", e.code, seen[e.code])}
+		if e.pos.X != 0 {
+			roles[i].Pos = Point{X: e.pos.X, Y: e.pos.Depth}
+			continue
+		}
+		byDepth[e.pos.Depth] = append(byDepth[e.pos.Depth], i)
+	}
+	for d, band := range byDepth {
+		for k, i := range band {
+			roles[i].Pos = Point{X: (float64(k) + 0.5) / float64(len(band)), Y: d}
+		}
+	}
+	return roles, nil
+}
@@ -0,0 +1,213 @@
+package formation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Line indexes a Shape: defence, midfield, attack.
+const (
+	DefenceLine = iota
+	MidfieldLine
+	AttackLine
+)
+
+var lineNames = [3]string{"def", "mid", "att"}
+
+// Priority orders the lines for breaking ties when apportioning players: the
+// earlier line gets the extra player. The zero value is not a valid order and
+// stands for DefaultPriority.
+type Priority [3]int
+
+// DefaultPriority settles ties towards defence, then midfield.
+var DefaultPriority = Priority{DefenceLine, MidfieldLine, AttackLine}
+
+func (p Priority) String() string {
+	names := make([]string, len(p))
+	for i, line := range p {
+		names[i] = lineNames[line]
+	}
+	return strings.Join(names, ",")
+}
+
+func (p Priority) valid() bool {
+	seen := [3]bool{}
+	for _, line := range p {
+		if line < 0 || line >= len(seen) || seen[line] {
+			return false
+		}
+		seen[line] = true
+	}
+	return true
+}
+
+// ParsePriority reads a line order such as "att,mid,def". Lines left out
+// follow in their usual order, so "att" is att,def,mid.
+func ParsePriority(s string) (Priority, error) {
+	p := Priority{}
+	seen := [3]bool{}
+	n := 0
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		line := -1
+		for i, name := range lineNames {
+			if field == name {
+				line = i
+			}
+		}
+		if line < 0 {
+			return Priority{}, fmt.Errorf("unknown line %q (want def, mid or att)", field)
+		}
+		if seen[line] {
+			return Priority{}, fmt.Errorf("line %q given twice", field)
+		}
+		seen[line] = true
+		p[n] = line
+		n++
+	}
+	for line := range seen {
+		if !seen[line] {
+			p[n] = line
+			n++
+		}
+	}
+	return p, nil
+}
+
+// Apportion scales a shape to the given number of outfield players with the
+// largest-remainder method: each line gets the whole part of its quota
+// (its share of the source times seats) and the players left over go to the
+// largest fractional parts, ties settled by p. A line the source plays keeps
+// at least one player while there are seats for every such line; the player
+// comes from the largest line, the one latest in p on a tie.
+func Apportion(from Shape, seats int, p Priority) (Shape, error) {
+	if !p.valid() {
+		p = DefaultPriority
+	}
+	total := from[0] + from[1] + from[2]
+	if total <= 0 {
+		return Shape{}, fmt.Errorf("source formation has no players in any line")
+	}
+	if seats <= 0 {
+		return Shape{}, fmt.Errorf("need at least 1 outfield player, got %d", seats)
+	}
+
+	rank := [3]int{}
+	for i, line := range p {
+		rank[line] = i
+	}
+	var to Shape
+	remainder := [3]int{} // in units of 1/total
+	left := seats
+	for i, n := range from {
+		to[i] = n * seats / total
+		remainder[i] = n * seats % total
+		left -= to[i]
+	}
+	order := []int{DefenceLine, MidfieldLine, AttackLine}
+	sort.SliceStable(order, func(a, b int) bool {
+		if remainder[order[a]] != remainder[order[b]] {
+			return remainder[order[a]] > remainder[order[b]]
+		}
+		return rank[order[a]] < rank[order[b]]
+	})
+	for _, line := range order[:left] {
+		to[line]++
+	}
+
+	played := 0
+	for _, n := range from {
+		if n > 0 {
+			played++
+		}
+	}
+	if seats >= played {
+		for line, n := range from {
+			if n == 0 || to[line] > 0 {
+				continue
+			}
+			donor := -1
+			for _, d := range p {
+				if donor < 0 || to[d] >= to[donor] {
+					donor = d
+				}
+			}
+			to[donor]--
+			to[line]++
+		}
+	}
+	return to, nil
+}
+
+// ConvertProportional scales the source formation to the given number of
+// outfield players line by line (see Apportion), so a 5-3-2 and a 3-4-3
+// reduce to different shapes, then fills the shape's slots by optimal
+// assignment. Converting up, the extra slots are added and named after
+// their slot ("CM_Add_1"). Flex roles belong to no line and do not count
+// towards the source's balance.
+func ConvertProportional(source []Role, outfield int, opts Options) ([]Role, error) {
+	from := ShapeOf(source)
+	shape, err := Apportion(from, outfield, opts.priority())
+	if err != nil {
+		return nil, err
+	}
+	slots := shape.Slots()
+	if opts.Trace != nil {
+		total := float64(from[0] + from[1] + from[2])
+		quotas := make([]string, len(from))
+		for i, n := range from {
+			quotas[i] = fmt.Sprintf("%s %.2f", lineNames[i], float64(n)*float64(outfield)/total)
+		}
+		opts.Trace.Add(Step{
+			Rule:   fmt.Sprintf("Proportional: scale %s to %d outfield", from, outfield),
+			Reason: fmt.Sprintf("quotas %s; largest remainders first, ties by %s; gives %s", strings.Join(quotas, ", "), opts.priority(), shape),
+		})
+	}
+	if len(slots) <= len(source) {
+		return ConvertOptimal(source, slots, opts)
+	}
+
+	c := fill(source, shape, opts.compat())
+	if opts.Trace != nil {
+		added := []Role{}
+		for _, r := range c.Roles {
+			if strings.Contains(r.Name, "_Add_") {
+				added = append(added, r)
+			}
+		}
+		opts.Trace.Add(Step{
+			Rule:   "Proportional: fill " + shape.String(),
+			Chosen: c.Roles,
+			Reason: fmt.Sprintf("source players take the slots they cover best (fit %.2f); %d added: %s", c.Fit, len(added), listRoles(added)),
+		})
+	}
+	return c.Roles, nil
+}
+
+// Convert11to7Proportional is the proportional alternative to
+// Convert11to7Relational: six outfield players in the source's balance.
+func Convert11to7Proportional(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertProportional(formation11, 6, opts)
+}
+
+// Convert11to8Proportional is the proportional alternative to
+// Convert11to8Relational.
+func Convert11to8Proportional(formation11 []Role, opts Options) ([]Role, error) {
+	return ConvertProportional(formation11, 7, opts)
+}
+
+// Convert7to11Proportional is the proportional alternative to
+// Convert7to11RuleBased.
+func Convert7to11Proportional(formation7 []Role, opts Options) ([]Role, error) {
+	return ConvertProportional(formation7, 10, opts)
+}
+
+// Convert8to11Proportional is the proportional alternative to
+// Convert8to11Relational.
+func Convert8to11Proportional(formation8 []Role, opts Options) ([]Role, error) {
+	return ConvertProportional(formation8, 10, opts)
+}
@@ -0,0 +1,48 @@
+package formation
+
+import "testing"
+
+func TestApportion(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     Shape
+		seats    int
+		priority string
+		want     Shape
+	}{
+		{"whole quotas", Shape{4, 4, 2}, 5, "def,mid,att", Shape{2, 2, 1}},
+		{"largest remainder", Shape{3, 5, 2}, 6, "def,mid,att", Shape{2, 3, 1}},
+		{"remainder tie to defence", Shape{4, 4, 2}, 6, "def,mid,att", Shape{3, 2, 1}},
+		{"remainder tie to midfield", Shape{4, 4, 2}, 6, "mid", Shape{2, 3, 1}},
+		{"tie skips a smaller remainder", Shape{4, 4, 2}, 6, "att", Shape{3, 2, 1}},
+		{"scaling up", Shape{2, 3, 1}, 10, "def,mid,att", Shape{3, 5, 2}},
+		{"empty lines get a player from the largest", Shape{8, 1, 1}, 3, "def,mid,att", Shape{1, 1, 1}},
+		{"donor tie goes to the latest in priority", Shape{5, 4, 1}, 4, "def,mid,att", Shape{2, 1, 1}},
+		{"donor tie reversed", Shape{5, 4, 1}, 4, "mid,def,att", Shape{1, 2, 1}},
+		{"too few seats for every line", Shape{8, 1, 1}, 2, "def,mid,att", Shape{2, 0, 0}},
+		{"unplayed lines stay empty", Shape{5, 5, 0}, 6, "def,mid,att", Shape{3, 3, 0}},
+	}
+	for _, tt := range tests {
+		p, err := ParsePriority(tt.priority)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := Apportion(tt.from, tt.seats, p)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Apportion(%s, %d, %s) = %s, want %s", tt.name, tt.from, tt.seats, p, got, tt.want)
+		}
+	}
+}
+
+func TestApportionRejects(t *testing.T) {
+	if _, err := Apportion(Shape{}, 6, DefaultPriority); err == nil {
+		t.Error("empty source: no error")
+	}
+	if _, err := Apportion(Shape{4, 4, 2}, 0, DefaultPriority); err == nil {
+		t.Error("no seats: no error")
+	}
+}
@@ -0,0 +1,136 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Quality compares a conversion's result with its source. The measures are
+// in [0, 1], higher being better. Score is the mean of Ratio, Balance and
+// Fidelity, scaled by Size so a result short of or over its format's
+// players never scores well.
+type Quality struct {
+	Ratio    float64 // 1 minus the distance between the line shares (see shareDistance)
+	Balance  float64 // 1 minus the share of wide players one flank has over the other
+	Fidelity float64 // source players kept (repurposed ones at half weight, invented ones at none) per place
+	Size     float64 // 1 minus the players missing or extra, as a share of the places
+	Score    float64
+
+	Kept       int // source players in the result in their own role
+	Repurposed int // source players in the result in another role, or renamed
+	Invented   int // result players not in the source ("CM_Fill_1", "ST_Extra_1"), or a source player used twice
+	Dropped    int // source players left out
+	Places     int // outfield players the target format has
+
+	From, To Shape
+}
+
+func (q Quality) String() string {
+	return fmt.Sprintf("%.2f (ratio %.2f, balance %.2f, fidelity %.2f, size %.2f)", q.Score, q.Ratio, q.Balance, q.Fidelity, q.Size)
+}
+
+// Report describes the quality over several lines, for the command line
+// tools.
+func (q Quality) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Score:           %.2f\n", q.Score)
+	fmt.Fprintf(&b, "Line ratios:     %.2f (%s -> %s)\n", q.Ratio, q.From, q.To)
+	fmt.Fprintf(&b, "Lateral balance: %.2f\n", q.Balance)
+	fmt.Fprintf(&b, "Fidelity:        %.2f (%d kept, %d repurposed, %d invented, %d dropped)\n", q.Fidelity, q.Kept, q.Repurposed, q.Invented, q.Dropped)
+	fmt.Fprintf(&b, "Size:            %.2f (%d of %d places)\n", q.Size, q.Kept+q.Repurposed+q.Invented, q.Places)
+	return b.String()
+}
+
+// Assess scores a conversion of source into result for a format with the
+// given number of outfield places. Players are matched by name, which every
+// converter keeps for the source players it uses; a result player under a
+// new name takes the place of a source player left out, when there is one,
+// and counts as repurposed (the greedy 11→7 rules field one of the dropped
+// players as "Flex_1").
+//
+// Ratio is how well the result keeps the source's share of defenders,
+// midfielders and attackers. Balance counts the full-backs and the wide
+// midfielders one flank has over the other (see flanks), as a share of the
+// result. Fidelity is the kept players, plus half the repurposed ones, over
+// the places: an invented player fills a place but earns nothing, so a
+// conversion that adds players scores below one that keeps or moves them.
+// Size is 1 less the players missing or extra, over the places.
+func Assess(source, result []Role, places int) Quality {
+	q := Quality{From: ShapeOf(source), To: ShapeOf(result), Ratio: 1, Balance: 1, Fidelity: 1, Size: 1, Places: places}
+	if q.From != (Shape{}) && q.To != (Shape{}) {
+		q.Ratio = 1 - shareDistance(q.From, q.To)
+	}
+
+	if len(result) > 0 {
+		over := 0
+		for _, rt := range []RoleType{FullBack, WideMidfielder} {
+			left, right := flanks(FilterRoles(result, []RoleType{rt}))
+			over += max(left-right, right-left)
+		}
+		q.Balance = 1 - float64(over)/float64(len(result))
+	}
+
+	byName := map[string]Role{}
+	for _, r := range source {
+		byName[r.Name] = r
+	}
+	used := map[string]bool{}
+	renamed := 0
+	for _, r := range result {
+		src, ok := byName[r.Name]
+		switch {
+		case used[r.Name]:
+			q.Invented++
+		case !ok:
+			renamed++
+		case src.RoleType == r.RoleType:
+			q.Kept++
+		default:
+			q.Repurposed++
+		}
+		used[r.Name] = true
+	}
+	for _, r := range source {
+		if !used[r.Name] {
+			q.Dropped++
+		}
+	}
+	// New names stand for dropped players while there are any
+	stood := min(renamed, q.Dropped)
+	q.Repurposed += stood
+	q.Dropped -= stood
+	q.Invented += renamed - stood
+
+	if places > 0 {
+		q.Fidelity = min(1, (float64(q.Kept)+0.5*float64(q.Repurposed))/float64(places))
+		q.Size = max(0, 1-math.Abs(float64(len(result)-places))/float64(places))
+	}
+	q.Score = q.Size * (q.Ratio + q.Balance + q.Fidelity) / 3
+	return q
+}
+
+// Scored is one strategy's conversion and its quality.
+type Scored struct {
+	Strategy string
+	Roles    []Role
+	Quality  Quality
+}
+
+// Compare runs every strategy on source and returns the results best first,
+// ties by strategy name, each assessed for the given number of places.
+// Strategies that fail or return nothing are left out.
+func Compare(source []Role, strategies map[string]Converter, places int, opts Options) []Scored {
+	opts.Trace = nil
+	scored := []Scored{}
+	for _, name := range StrategyNames(strategies) {
+		roles, err := strategies[name](source, opts)
+		if err != nil || len(roles) == 0 {
+			continue
+		}
+		scored = append(scored, Scored{Strategy: name, Roles: roles, Quality: Assess(source, roles, places)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Quality.Score > scored[j].Quality.Score+1e-12 })
+	return scored
+}
@@ -0,0 +1,91 @@
+package formation
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestAssessDuplicatesAreInvented(t *testing.T) {
+	cd := Role{RoleType: CenterBack, Name: "CD_8_1"}
+	cm := Role{RoleType: CentralMidfielder, Name: "CM_8_1"}
+	// 8→11 relational output fields some players twice
+	q := Assess([]Role{cd, cm}, []Role{cd, cd, cm, cm}, 4)
+	if q.Kept != 2 || q.Invented != 2 || q.Repurposed != 0 || q.Dropped != 0 {
+		t.Errorf("kept %d, invented %d, repurposed %d, dropped %d; want 2, 2, 0, 0", q.Kept, q.Invented, q.Repurposed, q.Dropped)
+	}
+	if !near(q.Fidelity, 0.5) {
+		t.Errorf("fidelity %.2f, want 0.50", q.Fidelity)
+	}
+}
+
+func TestAssessRenamedStandsForDropped(t *testing.T) {
+	source, err := ParseNumericalFormationInput11("442")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Convert11to7Relational(source, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := Assess(source, result, 6)
+	if q.Kept != 5 || q.Repurposed != 1 || q.Invented != 0 || q.Dropped != 4 {
+		t.Errorf("kept %d, repurposed %d, invented %d, dropped %d; want 5, 1, 0, 4", q.Kept, q.Repurposed, q.Invented, q.Dropped)
+	}
+}
+
+func TestAssessSize(t *testing.T) {
+	source, err := ParseNumericalFormationInput11("442")
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := Assess(source, source[:5], 10)
+	if !near(short.Size, 0.5) || short.Dropped != 5 || short.Score > 0.5 {
+		t.Errorf("five of ten: size %.2f, dropped %d, score %.2f; want 0.50, 5, at most 0.50", short.Size, short.Dropped, short.Score)
+	}
+
+	over := append(append([]Role{}, source...), Role{RoleType: Striker, Name: "ST_Extra_1"}, Role{RoleType: Striker, Name: "ST_Extra_2"})
+	q := Assess(source, over, 10)
+	if !near(q.Size, 0.8) || q.Invented != 2 || !near(q.Fidelity, 1) {
+		t.Errorf("twelve for ten: size %.2f, invented %d, fidelity %.2f; want 0.80, 2, 1", q.Size, q.Invented, q.Fidelity)
+	}
+	if whole := Assess(source, source, 10); q.Score >= whole.Score {
+		t.Errorf("twelve for ten scores %.2f, not below ten for ten (%.2f)", q.Score, whole.Score)
+	}
+
+	if empty := Assess(source, nil, 10); empty.Score != 0 || empty.Dropped != 10 {
+		t.Errorf("no result: score %.2f, dropped %d; want 0, 10", empty.Score, empty.Dropped)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	source, err := ParseNumericalFormationInput11("442")
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategies := map[string]Converter{
+		"whole": func(roles []Role, _ Options) ([]Role, error) { return roles, nil },
+		"same":  func(roles []Role, _ Options) ([]Role, error) { return roles, nil },
+		"short": func(roles []Role, _ Options) ([]Role, error) { return roles[:7], nil },
+		"over": func(roles []Role, _ Options) ([]Role, error) {
+			return append(append([]Role{}, roles...), Role{RoleType: Striker, Name: "ST_Extra_1"}), nil
+		},
+		"fails": func([]Role, Options) ([]Role, error) { return nil, errors.New("no") },
+		"empty": func([]Role, Options) ([]Role, error) { return nil, nil },
+	}
+	got := []string{}
+	for _, s := range Compare(source, strategies, 10, Options{}) {
+		got = append(got, s.Strategy)
+	}
+	want := []string{"same", "whole", "over", "short"}
+	if len(got) != len(want) {
+		t.Fatalf("Compare ranks %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Compare ranks %v, want %v", got, want)
+		}
+	}
+}
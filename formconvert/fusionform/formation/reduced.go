@@ -0,0 +1,103 @@
+package formation
+
+import "fmt"
+
+// Reduce takes n players off a formation, for a red card or an injury with
+// no substitutes left, and returns the players left and those taken off.
+//
+// A Flex role goes first, having no line to hold. After that each player
+// comes from the line latest in opts.Priority (attack, with the default)
+// that still has more than one player, or the latest line with anyone when
+// every line is down to one. Within the line the role kept last by the
+// reductions' own priorities is the one sacrificed: SelectRoles keeps the
+// line's central role and those that cover it best, so a wide midfielder
+// goes before a central one and a full-back before a centre-back.
+//
+// When the attack is left with more players than the midfield, one attacker
+// drops into midfield as a wide midfielder so the side stays compact.
+func Reduce(roles []Role, n int, opts Options) ([]Role, []Role, error) {
+	if n < 0 {
+		return nil, nil, fmt.Errorf("cannot take off %d players", n)
+	}
+	if n >= len(roles) {
+		return nil, nil, fmt.Errorf("cannot take %d players off a side of %d", n, len(roles))
+	}
+	compat, trace, priority := opts.compat(), opts.Trace, opts.priority()
+	remaining := append([]Role{}, roles...)
+	removed := []Role{}
+
+	for i := 0; i < n; i++ {
+		flex := FilterRoles(remaining, []RoleType{Flexible})
+		if len(flex) > 0 {
+			remaining = removeLast(remaining, flex[0])
+			removed = append(removed, flex[0])
+			trace.Add(Step{Rule: fmt.Sprintf("Player %d off: Flex first", i+1), Chosen: flex[:1], Reason: "a Flex role has no line to hold"})
+			continue
+		}
+
+		shape := ShapeOf(remaining)
+		line := -1
+		for _, l := range priority {
+			if shape[l] > 1 {
+				line = l
+			}
+		}
+		if line < 0 { // every line is down to one
+			for _, l := range priority {
+				if shape[l] > 0 {
+					line = l
+				}
+			}
+		}
+		if line < 0 {
+			return nil, nil, fmt.Errorf("no outfield player left to take off")
+		}
+		lineRoles := FilterRoles(remaining, lineTypes[line])
+		keep := SelectRoles(lineRoles, len(lineRoles)-1, lineTypes[line][0], compat)
+		off := RemoveRoles(lineRoles, keep)
+		if len(off) == 0 { // a duplicate of a kept role; it goes
+			off = lineRoles
+		}
+		off = off[len(off)-1:] // more than one left out when some cover nothing; the last goes
+		remaining = removeLast(remaining, off[0])
+		removed = append(removed, off...)
+		trace.Add(Step{
+			Rule:       fmt.Sprintf("Player %d off: %s loses one", i+1, lineNames[line]),
+			Candidates: lineRoles,
+			Chosen:     off,
+			Reason:     fmt.Sprintf("%s gives up a player first (priority %s); keeps %s", lineNames[line], priority, coverOrder(lineTypes[line][0], compat)),
+		})
+	}
+
+	shape := ShapeOf(remaining)
+	if shape[AttackLine] > shape[MidfieldLine] {
+		attackers := FilterRoles(remaining, lineTypes[AttackLine])
+		moved := SelectRoles(attackers, 1, WideMidfielder, compat)
+		if len(moved) == 0 {
+			moved = attackers[len(attackers)-1:]
+		}
+		for i, r := range remaining {
+			if r == moved[0] {
+				remaining[i] = Role{RoleType: WideMidfielder, Name: r.Name, Pos: r.Pos}
+				trace.Add(Step{
+					Rule:   "Rebalance",
+					Chosen: remaining[i : i+1],
+					Reason: fmt.Sprintf("attack (%d) outnumbered midfield (%d); %s drops back", shape[AttackLine], shape[MidfieldLine], r.Name),
+				})
+				break
+			}
+		}
+	}
+	return remaining, removed, nil
+}
+
+// removeLast returns roles without the last copy of r, so a duplicated role
+// loses one player, not all of them.
+func removeLast(roles []Role, r Role) []Role {
+	for i := len(roles) - 1; i >= 0; i-- {
+		if roles[i] == r {
+			return append(append([]Role{}, roles[:i]...), roles[i+1:]...)
+		}
+	}
+	return roles
+}
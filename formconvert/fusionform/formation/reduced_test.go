@@ -0,0 +1,21 @@
+package formation
+
+import "testing"
+
+func TestReduceDuplicatedRoles(t *testing.T) {
+	// 8→11 relational output repeats roles, e.g. CD_8_1 twice
+	cd := Role{RoleType: CenterBack, Name: "CD_8_1"}
+	cm := Role{RoleType: CentralMidfielder, Name: "CM_8_1"}
+	st := Role{RoleType: Striker, Name: "ST_8_1"}
+	roles := []Role{cd, cd, cm, cm, st}
+	left, off, err := Reduce(roles, 2, Options{Priority: Priority{AttackLine, MidfieldLine, DefenceLine}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(off) != 2 || len(left) != 3 {
+		t.Fatalf("took off %v, left %v; want 2 off and 3 left", off, left)
+	}
+	if off[0] != cd || off[1] != cm {
+		t.Errorf("took off %v, want one copy of CD_8_1, then of CM_8_1", off)
+	}
+}
@@ -0,0 +1,98 @@
+// Package formation holds the role model shared by the FusionForm converters
+// and data tools: role types and roles, parsing of formation strings into
+// role lists, and the conversions between 11-, 8- and 7-a-side formations.
+package formation
+
+import "sort"
+
+// RoleType and Role structs
+type RoleType string
+
+const (
+	CenterBack        RoleType = "CD"
+	FullBack          RoleType = "FB"
+	CentralMidfielder RoleType = "CM"
+	WideMidfielder    RoleType = "WM"
+	Striker           RoleType = "ST"
+	Flexible          RoleType = "Flex" // For 7-a-side flexible role(maunual decide by coach)
+)
+
+type Role struct {
+	RoleType RoleType
+	Name     string
+	Pos      Point // nominal position (see Place); zero when not placed
+}
+
+// Counts tallies the roles of each type.
+func Counts(roles []Role) map[RoleType]int {
+	counts := make(map[RoleType]int)
+	for _, role := range roles {
+		counts[role.RoleType]++
+	}
+	return counts
+}
+
+// FilterRoles keeps the roles whose type is one of roleTypes.
+func FilterRoles(roles []Role, roleTypes []RoleType) []Role {
+	filtered := []Role{}
+	for _, role := range roles {
+		for _, rt := range roleTypes {
+			if role.RoleType == rt {
+				filtered = append(filtered, role)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// SelectRoles picks up to n roles that can fill a slot of role type to,
+// those compat scores highest first. Roles with equal scores are taken in
+// slice order, so when the better covers run out the rule falls back to
+// the rest of the pool as it comes.
+func SelectRoles(roles []Role, n int, to RoleType, compat Compatibility) []Role {
+	candidates := covering(roles, to, compat)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return compat.Score(candidates[i].RoleType, to) > compat.Score(candidates[j].RoleType, to)
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// SelectNatural picks up to n roles for a slot of role type to: players in
+// that role first, then any other role compat lets cover the slot, in slice
+// order whatever its score.
+func SelectNatural(roles []Role, n int, to RoleType, compat Compatibility) []Role {
+	selected := []Role{}
+	for _, role := range roles {
+		if role.RoleType == to && len(selected) < n {
+			selected = append(selected, role)
+		}
+	}
+	for _, role := range covering(roles, to, compat) {
+		if role.RoleType != to && len(selected) < n {
+			selected = append(selected, role)
+		}
+	}
+	return selected
+}
+
+// RemoveRoles returns allRoles without rolesToRemove.
+func RemoveRoles(allRoles []Role, rolesToRemove []Role) []Role {
+	remaining := []Role{}
+	for _, role := range allRoles {
+		isRemoved := false
+		for _, roleToRemove := range rolesToRemove {
+			if role == roleToRemove {
+				isRemoved = true
+				break
+			}
+		}
+		if !isRemoved {
+			remaining = append(remaining, role)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,146 @@
+package formation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Distance is how far apart two formations are, each component in [0, 1].
+type Distance struct {
+	Lines     float64 // difference in the share of defenders, midfielders and attackers
+	Roles     float64 // difference in the share of each role type
+	Positions float64 // mean distance between matched nominal positions, as a share of the pitch diagonal
+	Total     float64 // mean of the three
+}
+
+func (d Distance) String() string {
+	return fmt.Sprintf("%.3f (lines %.3f, roles %.3f, positions %.3f)", d.Total, d.Lines, d.Roles, d.Positions)
+}
+
+// FormationDistance compares two formations in dashed notation ("4-1-4-1",
+// "4-3-3"); see LineDistance.
+func FormationDistance(a, b string) (Distance, error) {
+	la, err := ParseLines(a)
+	if err != nil {
+		return Distance{}, err
+	}
+	lb, err := ParseLines(b)
+	if err != nil {
+		return Distance{}, err
+	}
+	return LineDistance(la, lb), nil
+}
+
+// LineDistance compares two formations given as per-line counts, back to
+// front. The line and role components compare shares rather than counts,
+// so formations of different sizes can be compared. The positional component
+// matches the players of the smaller formation to nominal positions of the
+// larger (see LinePositions) at least total distance, which tells apart
+// shapes with the same lines and roles, such as 4-1-4-1 and 4-5-1.
+func LineDistance(a, b []int) Distance {
+	rolesA, rolesB := lineRoleList(a), lineRoleList(b)
+	d := Distance{
+		Lines: shareDistance(ShapeOf(rolesA), ShapeOf(rolesB)),
+		Roles: mixDistance(Counts(rolesA), Counts(rolesB)),
+	}
+
+	pa, pb := LinePositions(a), LinePositions(b)
+	if len(pa) > len(pb) {
+		pa, pb = pb, pa
+	}
+	if len(pa) > 0 {
+		cost := make([][]float64, len(pa))
+		for i := range pa {
+			cost[i] = make([]float64, len(pb))
+			for j := range pb {
+				cost[i][j] = Pitch11.Distance(pa[i], pb[j])
+			}
+		}
+		total := 0.0
+		for i, j := range hungarian(cost) {
+			total += cost[i][j]
+		}
+		d.Positions = total / float64(len(pa)) / math.Hypot(Pitch11.Length, Pitch11.Width)
+	}
+	d.Total = (d.Lines + d.Roles + d.Positions) / 3
+	return d
+}
+
+// lineRoleList is the roles of a formation's lines (see LineRoles) as a
+// role list.
+func lineRoleList(lines []int) []Role {
+	roles := []Role{}
+	for _, line := range LineRoles(lines) {
+		for _, rt := range line {
+			roles = append(roles, Role{RoleType: rt})
+		}
+	}
+	return roles
+}
+
+// mixDistance is half the L1 distance between the role type shares of two
+// role counts, in [0, 1].
+func mixDistance(a, b map[RoleType]int) float64 {
+	na, nb := 0, 0
+	for _, n := range a {
+		na += n
+	}
+	for _, n := range b {
+		nb += n
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	d := 0.0
+	for _, rt := range RoleTypes {
+		d += math.Abs(float64(a[rt])/float64(na) - float64(b[rt])/float64(nb))
+	}
+	return d / 2
+}
+
+// LinePositions gives the nominal position of every player in a formation's
+// lines: the lines are spread evenly between the depths of the defence and
+// the attack used by Place, and each line evenly across the pitch.
+func LinePositions(lines []int) []Point {
+	points := []Point{}
+	for i, n := range lines {
+		depth := lineDepth[MidfieldLine]
+		if len(lines) > 1 {
+			depth = lineDepth[DefenceLine] + (lineDepth[AttackLine]-lineDepth[DefenceLine])*float64(i)/float64(len(lines)-1)
+		}
+		for k := 0; k < n; k++ {
+			points = append(points, Point{X: (float64(k) + 0.5) / float64(n), Y: depth})
+		}
+	}
+	return points
+}
+
+// Match is a known formation and its distance from the one looked up.
+type Match struct {
+	Formation string
+	Distance  Distance
+}
+
+// Nearest returns the k formations of vocabulary nearest to formation (all
+// of them when k <= 0), nearest first and ties in vocabulary order.
+// Vocabulary entries that do not parse are skipped.
+func Nearest(formation string, vocabulary []string, k int) ([]Match, error) {
+	lines, err := ParseLines(formation)
+	if err != nil {
+		return nil, err
+	}
+	matches := []Match{}
+	for _, v := range vocabulary {
+		vl, err := ParseLines(v)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, Match{Formation: v, Distance: LineDistance(lines, vl)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Distance.Total < matches[j].Distance.Total-1e-12 })
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
@@ -0,0 +1,157 @@
+package formation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style is a tactical preset. It sets how many players each line gets in
+// each format and which roles fill a line when it grows, so the same 2-3-1
+// expands to a 5-3-2 for a defensive plan and a 4-3-3 for a counter-attacking
+// one.
+type Style struct {
+	Name        string
+	Description string
+	Lines       Shape // 11-a-side defenders, midfielders and attackers (10 in all)
+	Lines7      Shape // 7-a-side CD, CM and ST; a Flex makes up six
+	Lines8      Shape // 8-a-side CD, CM/WM and ST
+	// Adds lists, per line, the roles that fill the places a smaller format
+	// did not have, in order; the last repeats.
+	Adds [3][]RoleType
+	// Fill is the order 8→11 fills any places left once every line is done.
+	Fill []RoleType
+}
+
+// The presets. Balanced is the conversion the tools have always made.
+var (
+	Balanced = &Style{
+		Name:        "balanced",
+		Description: "back four, midfield four, front two",
+		Lines:       Shape{4, 4, 2},
+		Lines7:      Shape{2, 2, 1},
+		Lines8:      Shape{2, 3, 2},
+		Adds:        [3][]RoleType{{FullBack}, {WideMidfielder}, {Striker}},
+		Fill:        []RoleType{FullBack, WideMidfielder, CentralMidfielder},
+	}
+	Defensive = &Style{
+		Name:        "defensive",
+		Description: "back five with three centre-backs",
+		Lines:       Shape{5, 3, 2},
+		Lines7:      Shape{3, 2, 1},
+		Lines8:      Shape{3, 3, 1},
+		Adds:        [3][]RoleType{{FullBack, FullBack, CenterBack}, {CentralMidfielder}, {Striker}},
+		Fill:        []RoleType{FullBack, CenterBack, CentralMidfielder},
+	}
+	Attacking = &Style{
+		Name:        "attacking",
+		Description: "back three and a front three",
+		Lines:       Shape{3, 4, 3},
+		Lines7:      Shape{2, 2, 2},
+		Lines8:      Shape{2, 2, 3},
+		Adds:        [3][]RoleType{{CenterBack}, {WideMidfielder}, {Striker}},
+		Fill:        []RoleType{WideMidfielder, Striker, CentralMidfielder},
+	}
+	Possession = &Style{
+		Name:        "possession",
+		Description: "crowded midfield, lone striker",
+		Lines:       Shape{4, 5, 1},
+		Lines7:      Shape{2, 3, 1},
+		Lines8:      Shape{2, 4, 1},
+		Adds:        [3][]RoleType{{FullBack}, {WideMidfielder, WideMidfielder, CentralMidfielder}, {Striker}},
+		Fill:        []RoleType{CentralMidfielder, WideMidfielder, FullBack},
+	}
+	Counter = &Style{
+		Name:        "counter",
+		Description: "solid back four, quick front three",
+		Lines:       Shape{4, 3, 3},
+		Lines7:      Shape{3, 1, 2},
+		Lines8:      Shape{3, 2, 2},
+		Adds:        [3][]RoleType{{FullBack}, {CentralMidfielder}, {Striker}},
+		Fill:        []RoleType{FullBack, Striker, CentralMidfielder},
+	}
+)
+
+// Styles lists the presets, Balanced first.
+var Styles = []*Style{Balanced, Defensive, Attacking, Possession, Counter}
+
+// StyleNames lists the preset names in Styles order.
+func StyleNames() []string {
+	names := make([]string, len(Styles))
+	for i, s := range Styles {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// StyleByName returns the preset with the given name.
+func StyleByName(name string) (*Style, error) {
+	for _, s := range Styles {
+		if strings.EqualFold(s.Name, name) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown style %q (want %s)", name, strings.Join(StyleNames(), ", "))
+}
+
+// add returns the role for the i-th place (from 0) added to a line.
+func (s *Style) add(line, i int) RoleType {
+	adds := s.Adds[line]
+	return adds[min(i, len(adds)-1)]
+}
+
+// addOrder is the order 8→11 takes roles from the flexible pool to grow a
+// line: the line's added roles, then the role it already plays.
+func (s *Style) addOrder(line int, central RoleType) []RoleType {
+	order := []RoleType{}
+	seen := map[RoleType]bool{}
+	for _, rt := range append(append([]RoleType{}, s.Adds[line]...), central) {
+		if !seen[rt] {
+			seen[rt] = true
+			order = append(order, rt)
+		}
+	}
+	return order
+}
+
+// Target7 is the 7-a-side slots the optimal converter fills for this style.
+func (s *Style) Target7() []RoleType {
+	target := []RoleType{}
+	for i, rt := range []RoleType{CenterBack, CentralMidfielder, Striker} {
+		for j := 0; j < s.Lines7[i]; j++ {
+			target = append(target, rt)
+		}
+	}
+	for len(target) < 6 {
+		target = append(target, Flexible)
+	}
+	return target
+}
+
+// Target8 is the 8-a-side slots the optimal converter fills for this style.
+// A midfield of three or more has one wide midfielder.
+func (s *Style) Target8() []RoleType {
+	target := []RoleType{}
+	for i := 0; i < s.Lines8[0]; i++ {
+		target = append(target, CenterBack)
+	}
+	for i := 0; i < s.Lines8[1]; i++ {
+		if s.Lines8[1] >= 3 && i == s.Lines8[1]-1 {
+			target = append(target, WideMidfielder)
+		} else {
+			target = append(target, CentralMidfielder)
+		}
+	}
+	for i := 0; i < s.Lines8[2]; i++ {
+		target = append(target, Striker)
+	}
+	return target
+}
+
+var roleNames = map[RoleType]string{
+	CenterBack:        "Center Backs",
+	FullBack:          "Full-Backs",
+	CentralMidfielder: "Central Midfielders",
+	WideMidfielder:    "Wide Midfielders",
+	Striker:           "Strikers",
+	Flexible:          "Flex",
+}
@@ -0,0 +1,343 @@
+package formation
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//go:embed templates.csv
+var defaultTemplates string
+
+// Side is the part of the pitch a template role plays on.
+type Side string
+
+const (
+	Left   Side = "left"
+	Centre Side = "centre"
+	Right  Side = "right"
+)
+
+// TemplateRole is one player of a template: a sub-role the coach would name
+// ("LCB", "DM", "AM"), the role type the converters work with, and the side.
+type TemplateRole struct {
+	Sub      string
+	RoleType RoleType
+	Side     Side
+}
+
+// Template is a named formation, laid out line by line back to front and
+// each line left to right.
+type Template struct {
+	Name    string
+	Aliases []string
+	Lines   [][]TemplateRole
+}
+
+// Outfield is the number of outfield players in the template.
+func (t *Template) Outfield() int {
+	n := 0
+	for _, line := range t.Lines {
+		n += len(line)
+	}
+	return n
+}
+
+// Formation is the template's lines in dashed notation, e.g. "4-1-2-1-2".
+func (t *Template) Formation() string {
+	parts := make([]string, len(t.Lines))
+	for i, line := range t.Lines {
+		parts[i] = strconv.Itoa(len(line))
+	}
+	return strings.Join(parts, "-")
+}
+
+// Roles returns the template's role list, back to front. Each role is named
+// after its sub-role and placed where its line and side put it (see
+// LinePositions).
+func (t *Template) Roles() []Role {
+	counts := make([]int, len(t.Lines))
+	for i, line := range t.Lines {
+		counts[i] = len(line)
+	}
+	points := LinePositions(counts)
+	roles := []Role{}
+	for _, line := range t.Lines {
+		for _, r := range line {
+			roles = append(roles, Role{RoleType: r.RoleType, Name: r.Sub, Pos: points[len(roles)]})
+		}
+	}
+	return roles
+}
+
+// TemplateLibrary holds templates by name and alias.
+type TemplateLibrary struct {
+	templates []*Template
+	byKey     map[string]*Template
+}
+
+// Templates is the library the parsers look names up in: the built-in
+// templates, extended with Add or Read (see LoadTemplates).
+var Templates = DefaultTemplates()
+
+// DefaultTemplates returns a library of the built-in templates.
+func DefaultTemplates() *TemplateLibrary {
+	lib := &TemplateLibrary{byKey: map[string]*Template{}}
+	if err := lib.Read(strings.NewReader(defaultTemplates)); err != nil {
+		panic("formation: invalid built-in templates: " + err.Error())
+	}
+	return lib
+}
+
+// LoadTemplates adds the templates in a CSV file (see Read) to Templates,
+// replacing built-in ones of the same name or alias. An empty path adds
+// nothing.
+func LoadTemplates(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := Templates.Read(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// templateKey is how names are matched: lower case letters and digits only,
+// so "Diamond 4-4-2" and "diamond442" are the same template.
+func templateKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Add puts t in the library under its name and aliases, replacing any
+// template already known by one of them. Names must contain a letter, so a
+// template never shadows a numeric formation.
+func (lib *TemplateLibrary) Add(t *Template) error {
+	names := append([]string{t.Name}, t.Aliases...)
+	for _, name := range names {
+		if strings.IndexFunc(name, unicode.IsLetter) < 0 {
+			return fmt.Errorf("template name %q must contain a letter", name)
+		}
+	}
+	if t.Outfield() == 0 {
+		return fmt.Errorf("template %q has no players", t.Name)
+	}
+	seen := map[string]bool{}
+	for _, line := range t.Lines {
+		for _, r := range line {
+			if seen[r.Sub] {
+				return fmt.Errorf("template %q names %s twice", t.Name, r.Sub)
+			}
+			seen[r.Sub] = true
+		}
+	}
+	for _, name := range names {
+		if old := lib.byKey[templateKey(name)]; old != nil {
+			lib.remove(old)
+		}
+	}
+	lib.templates = append(lib.templates, t)
+	for _, name := range names {
+		lib.byKey[templateKey(name)] = t
+	}
+	return nil
+}
+
+func (lib *TemplateLibrary) remove(t *Template) {
+	for key, u := range lib.byKey {
+		if u == t {
+			delete(lib.byKey, key)
+		}
+	}
+	for i, u := range lib.templates {
+		if u == t {
+			lib.templates = append(lib.templates[:i], lib.templates[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup returns the template with the given name or alias.
+func (lib *TemplateLibrary) Lookup(name string) (*Template, bool) {
+	t, ok := lib.byKey[templateKey(name)]
+	return t, ok
+}
+
+// Suggest returns up to n templates whose names or aliases are close to an
+// unknown name, best first.
+func (lib *TemplateLibrary) Suggest(name string, n int) []string {
+	key := templateKey(name)
+	if key == "" || n <= 0 {
+		return nil
+	}
+	best := map[*Template]int{}
+	for alias, t := range lib.byKey {
+		d := editDistance(key, alias)
+		if strings.HasPrefix(alias, key) {
+			d = min(d, 1) // "christmas" for "christmas tree"
+		}
+		if d > max(2, len(key)/3) {
+			continue
+		}
+		if cur, ok := best[t]; !ok || d < cur {
+			best[t] = d
+		}
+	}
+	suggestions := make([]string, 0, len(best))
+	distance := map[string]int{}
+	for t, d := range best {
+		suggestions = append(suggestions, t.Name)
+		distance[t.Name] = d
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if distance[a] != distance[b] {
+			return distance[a] < distance[b]
+		}
+		return a < b
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// All returns the templates by size, largest first, then by name.
+func (lib *TemplateLibrary) All() []*Template {
+	all := append([]*Template{}, lib.templates...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Outfield() != all[j].Outfield() {
+			return all[i].Outfield() > all[j].Outfield()
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Read adds templates from CSV with a "name,aliases,lines" header. Aliases
+// are separated by "|"; lines run back to front separated by "/", each
+// player written SUB:ROLE left to right, e.g.
+//
+//	diamond 4-4-2,diamond,LB:FB LCB:CD RCB:CD RB:FB / DM:CM / LCM:CM RCM:CM / AM:CM / LS:ST RS:ST
+//
+// A player's side follows from their place in the line. Lines starting with
+// # are comments.
+func (lib *TemplateLibrary) Read(in io.Reader) error {
+	cr := csv.NewReader(in)
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) != 3 || strings.TrimSpace(header[0]) != "name" || strings.TrimSpace(header[1]) != "aliases" || strings.TrimSpace(header[2]) != "lines" {
+		return fmt.Errorf("header must be \"name,aliases,lines\"")
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		t := &Template{Name: strings.TrimSpace(rec[0])}
+		for _, alias := range strings.Split(rec[1], "|") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				t.Aliases = append(t.Aliases, alias)
+			}
+		}
+		for _, part := range strings.Split(rec[2], "/") {
+			players := strings.Fields(part)
+			roles := make([]TemplateRole, len(players))
+			for i, p := range players {
+				sub, code, ok := strings.Cut(p, ":")
+				rt, err := ParseRoleType(code)
+				if !ok || sub == "" || err != nil {
+					return fmt.Errorf("line %d: player %q must look like LCB:CD", line, p)
+				}
+				roles[i] = TemplateRole{Sub: sub, RoleType: rt, Side: sideOf(i, len(players))}
+			}
+			t.Lines = append(t.Lines, roles)
+		}
+		if err := lib.Add(t); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// sideOf is the side of the i-th of n players in a line, left to right: the
+// outer thirds of the line are the flanks.
+func sideOf(i, n int) Side {
+	x := (float64(i) + 0.5) / float64(n)
+	switch {
+	case x < 1.0/3:
+		return Left
+	case x > 2.0/3:
+		return Right
+	}
+	return Centre
+}
+
+// templateRoles looks input up in Templates for a parser of the named format
+// with the given number of outfield players.
+func templateRoles(input string, outfield int, format string) ([]Role, bool, error) {
+	t, ok := Templates.Lookup(input)
+	if !ok {
+		return nil, false, nil
+	}
+	if t.Outfield() != outfield {
+		return nil, true, fmt.Errorf("template %q has %d outfield players; %s needs %d", t.Name, t.Outfield(), format, outfield)
+	}
+	return t.Roles(), true, nil
+}
+
+// unknownTemplate is the error for input that has a letter in it but names
+// no template, so it is no numeric formation either; nil for other input.
+func unknownTemplate(input string) error {
+	if strings.IndexFunc(input, unicode.IsLetter) < 0 {
+		return nil
+	}
+	if s := Templates.Suggest(input, 3); len(s) > 0 {
+		return fmt.Errorf("unknown formation or template %q (did you mean %s?)", input, strings.Join(s, ", "))
+	}
+	return fmt.Errorf("unknown formation or template %q", input)
+}
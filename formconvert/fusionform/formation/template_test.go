@@ -0,0 +1,82 @@
+package formation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateLookup(t *testing.T) {
+	lib := DefaultTemplates()
+	tests := []struct {
+		name, want, formation string
+	}{
+		{"diamond", "diamond 4-4-2", "4-1-2-1-2"},
+		{"Diamond 4-4-2", "diamond 4-4-2", "4-1-2-1-2"},
+		{"diamond442", "diamond 4-4-2", "4-1-2-1-2"},
+		{"4-4-2 DIAMOND", "diamond 4-4-2", "4-1-2-1-2"},
+		{"christmas tree", "christmas tree", "4-3-2-1"},
+		{"pyramid", "7-a-side pyramid", "2-3-1"},
+		{"8-a-side pyramid", "8-a-side pyramid", "3-3-1"},
+	}
+	for _, tt := range tests {
+		tmpl, ok := lib.Lookup(tt.name)
+		if !ok {
+			t.Errorf("Lookup(%q): not found", tt.name)
+			continue
+		}
+		if tmpl.Name != tt.want || tmpl.Formation() != tt.formation {
+			t.Errorf("Lookup(%q) = %s (%s), want %s (%s)", tt.name, tmpl.Name, tmpl.Formation(), tt.want, tt.formation)
+		}
+	}
+	for _, name := range []string{"442", "4-4-2", "diamond 4-3-3", ""} {
+		if tmpl, ok := lib.Lookup(name); ok {
+			t.Errorf("Lookup(%q) = %s, want none", name, tmpl.Name)
+		}
+	}
+}
+
+func TestTemplateAddRejectsDigitNames(t *testing.T) {
+	lib := DefaultTemplates()
+	tmpl := &Template{Name: "4-4-2", Lines: [][]TemplateRole{{{Sub: "ST", RoleType: Striker}}}}
+	if err := lib.Add(tmpl); err == nil {
+		t.Error("Add accepted a template named 4-4-2")
+	}
+}
+
+func TestTemplateSuggest(t *testing.T) {
+	lib := DefaultTemplates()
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"christmas", []string{"christmas tree"}},
+		{"daimond", []string{"diamond 4-4-2"}},
+		{"wingbacks", []string{"wing-backs 3-5-2"}},
+		{"xyzzy", nil},
+	}
+	for _, tt := range tests {
+		got := lib.Suggest(tt.name, 3)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Suggest(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemplateInput(t *testing.T) {
+	roles, err := ParseNumericalFormationInput11("diamond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ShapeOf(roles); got != (Shape{4, 4, 2}) || roles[4].Name != "DM" {
+		t.Errorf("diamond parses as %s starting midfield with %s, want 4-4-2 with DM", got, roles[4].Name)
+	}
+
+	_, err = ParseNumericalFormationInput11("daimond")
+	if err == nil || !strings.Contains(err.Error(), "did you mean diamond 4-4-2?") {
+		t.Errorf("daimond: got %v, want a did-you-mean for diamond 4-4-2", err)
+	}
+	_, err = ParseNumericalFormationInput11("pyramid")
+	if err == nil || !strings.Contains(err.Error(), "6 outfield players") {
+		t.Errorf("pyramid as 11-a-side: got %v, want a size error", err)
+	}
+}
@@ -0,0 +1,157 @@
+package formation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options tune a conversion. The zero value converts with the built-in
+// compatibility matrix and records no trace.
+type Options struct {
+	Compat       Compatibility // nil means DefaultCompatibility()
+	Trace        *Trace        // when not nil, every rule's decision is appended
+	Priority     Priority      // tie-break order for proportional scaling; zero means DefaultPriority
+	Style        *Style        // line targets and role order; nil means Balanced
+	Constraints  *Constraints  // used by ConvertConstrained
+	Partnerships Graph         // used by ConvertPartnership; nil means Partnerships(source)
+}
+
+func (o Options) style() *Style {
+	if o.Style == nil {
+		return Balanced
+	}
+	return o.Style
+}
+
+func (o Options) compat() Compatibility {
+	if o.Compat == nil {
+		return DefaultCompatibility()
+	}
+	return o.Compat
+}
+
+func (o Options) priority() Priority {
+	if !o.Priority.valid() {
+		return DefaultPriority
+	}
+	return o.Priority
+}
+
+// Step is one decision a converter made.
+type Step struct {
+	Rule       string // e.g. "Rule 1: Preserve Central Defensive Core (2 CD)"
+	Candidates []Role // roles the rule could choose from
+	Chosen     []Role // roles it placed
+	Rejected   []Role // candidates it passed over
+	Reason     string
+}
+
+// Trace is the record of how a conversion reached its result, so coaches can
+// see which rule placed each role and why others were left out.
+type Trace struct {
+	Steps []Step
+}
+
+// Add appends a step. It does nothing on a nil trace, so converters call it
+// unconditionally.
+func (t *Trace) Add(s Step) {
+	if t != nil {
+		t.Steps = append(t.Steps, s)
+	}
+}
+
+// String lays the trace out one rule at a time.
+func (t *Trace) String() string {
+	if t == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i, s := range t.Steps {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n", s.Rule)
+		if s.Candidates != nil {
+			fmt.Fprintf(&b, "  candidates: %s\n", listRoles(s.Candidates))
+		}
+		if s.Candidates != nil || s.Chosen != nil {
+			fmt.Fprintf(&b, "  chosen:     %s\n", listRoles(s.Chosen))
+		}
+		if len(s.Rejected) > 0 {
+			fmt.Fprintf(&b, "  rejected:   %s\n", listRoles(s.Rejected))
+		}
+		if s.Reason != "" {
+			fmt.Fprintf(&b, "  why:        %s\n", s.Reason)
+		}
+	}
+	return b.String()
+}
+
+func listRoles(roles []Role) string {
+	if len(roles) == 0 {
+		return "none"
+	}
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = fmt.Sprintf("%s (%s)", r.Name, r.RoleType)
+	}
+	return strings.Join(names, ", ")
+}
+
+// covering returns the roles in pool that can fill a slot of role type to.
+func covering(pool []Role, to RoleType, compat Compatibility) []Role {
+	out := []Role{}
+	for _, r := range pool {
+		if compat.Score(r.RoleType, to) > 0 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// coverOrder describes the order compat fills a slot in, e.g.
+// "CD, then FB (0.8)".
+func coverOrder(to RoleType, compat Compatibility) string {
+	parts := []string{}
+	for _, rt := range compat.Covers(to) {
+		if rt == to {
+			parts = append(parts, string(rt))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (%.2g)", rt, compat.Score(rt, to)))
+	}
+	return strings.Join(parts, ", then ")
+}
+
+// pick is SelectRoles with the decision recorded as a step of the trace.
+func pick(trace *Trace, rule string, pool []Role, n int, to RoleType, compat Compatibility) []Role {
+	return pickBy(trace, rule, SelectRoles, coverOrder(to, compat), pool, n, to, compat)
+}
+
+// pickNatural is SelectNatural with the decision recorded as a step of the
+// trace.
+func pickNatural(trace *Trace, rule string, pool []Role, n int, to RoleType, compat Compatibility) []Role {
+	return pickBy(trace, rule, SelectNatural, string(to)+", then any cover in formation order", pool, n, to, compat)
+}
+
+func pickBy(trace *Trace, rule string, sel func([]Role, int, RoleType, Compatibility) []Role, order string, pool []Role, n int, to RoleType, compat Compatibility) []Role {
+	selected := sel(pool, n, to, compat)
+	if trace == nil {
+		return selected
+	}
+	candidates := covering(pool, to, compat)
+	reason := fmt.Sprintf("needs %d for %s; takes %s", n, to, order)
+	if len(selected) < n {
+		reason += fmt.Sprintf("; only %d available", len(selected))
+	} else if len(candidates) > len(selected) {
+		reason += "; the rest cover it less well or came later"
+	}
+	trace.Add(Step{
+		Rule:       rule,
+		Candidates: candidates,
+		Chosen:     selected,
+		Rejected:   RemoveRoles(candidates, selected),
+		Reason:     reason,
+	})
+	return selected
+}
@@ -0,0 +1,124 @@
+// Package formations loads the per-game formation CSVs in data/formations
+// (columns gameid, team, formation, players).
+package formations
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fusionform/teams"
+)
+
+// DefaultDir is where the Python scripts expect the formation files, relative
+// to the repository root.
+const DefaultDir = "data/formations"
+
+// Lineup is one team's shape in one game.
+type Lineup struct {
+	GameID      int64
+	Team        string
+	Formation   string // e.g. "4-2-3-1"
+	Players     string // shirt numbers per line, "GK;DEF;MID;...;ATT"
+	Season      string // from the file name, e.g. "1718"
+	Competition string // from the file name, e.g. "premier_league"
+	Source      string // file name and line, for reporting
+}
+
+// LoadDir reads every formations_*.csv file in dir, in file name order,
+// resolving team names through names.
+func LoadDir(dir string, names *teams.Registry) ([]Lineup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "formations_") && strings.HasSuffix(e.Name(), ".csv") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+
+	lineups := []Lineup{}
+	for _, name := range files {
+		l, err := LoadFile(filepath.Join(dir, name), names)
+		if err != nil {
+			return nil, err
+		}
+		lineups = append(lineups, l...)
+	}
+	return lineups, nil
+}
+
+// LoadFile reads one formations CSV. Season and competition are taken from a
+// file name of the form formations_<season>_<competition>.csv.
+func LoadFile(path string, names *teams.Registry) ([]Lineup, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	season, competition := parseFileName(filepath.Base(path))
+	r := csv.NewReader(file)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading header: %w", path, err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.TrimSpace(h)] = i
+	}
+	for _, required := range []string{"gameid", "team", "formation"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("%s: missing %q column", path, required)
+		}
+	}
+
+	lineups := []Lineup{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := r.FieldPos(0)
+		id, err := strconv.ParseInt(strings.TrimSpace(rec[col["gameid"]]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid gameid %q", path, line, rec[col["gameid"]])
+		}
+		l := Lineup{
+			GameID:      id,
+			Team:        names.Canonical(rec[col["team"]]),
+			Formation:   CleanFormation(rec[col["formation"]]),
+			Season:      season,
+			Competition: competition,
+			Source:      fmt.Sprintf("%s:%d", filepath.Base(path), line),
+		}
+		if i, ok := col["players"]; ok {
+			l.Players = strings.TrimSpace(rec[i])
+		}
+		lineups = append(lineups, l)
+	}
+	return lineups, nil
+}
+
+// CleanFormation strips the whitespace and stray quotes found in some rows,
+// matching clean_formation in the Python scripts.
+func CleanFormation(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `'"`)
+}
+
+func parseFileName(name string) (season, competition string) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "formations_"), ".csv")
+	season, competition, _ = strings.Cut(name, "_")
+	return season, competition
+}
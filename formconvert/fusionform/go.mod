@@ -0,0 +1,3 @@
+module fusionform
+
+go 1.24.0
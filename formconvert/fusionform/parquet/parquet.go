@@ -0,0 +1,604 @@
+// Package parquet is a small, dependency-free reader for flat Parquet files
+// such as the match results pandas writes with DataFrame.to_parquet.
+//
+// It supports required and optional top-level columns of every physical type,
+// PLAIN and dictionary encodings, data pages v1 and v2, and the UNCOMPRESSED,
+// SNAPPY and GZIP codecs. Nested columns and the DELTA encodings are not
+// supported and are reported as errors when read.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Type is a Parquet physical type.
+type Type int
+
+const (
+	Boolean Type = iota
+	Int32
+	Int64
+	Int96
+	Float
+	Double
+	ByteArray
+	FixedLenByteArray
+)
+
+var typeNames = []string{"BOOLEAN", "INT32", "INT64", "INT96", "FLOAT", "DOUBLE", "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY"}
+
+func (t Type) String() string {
+	if t >= 0 && int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Converted (legacy) types the reader interprets
+const (
+	convertedUTF8            = 0
+	convertedEnum            = 4
+	convertedDate            = 6
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+	convertedJSON            = 19
+)
+
+// Page types, encodings and codecs from parquet.thrift
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+
+	encodingPlain      = 0
+	encodingPlainDict  = 2
+	encodingRLE        = 3
+	encodingRLEDict    = 8
+	codecUncompressed  = 0
+	codecSnappy        = 1
+	codecGzip          = 2
+	repetitionOptional = 1
+)
+
+var codecNames = []string{"UNCOMPRESSED", "SNAPPY", "GZIP", "LZO", "BROTLI", "LZ4", "ZSTD", "LZ4_RAW"}
+
+// Column describes one top-level column of the file schema.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+
+	typeLength int
+	converted  int64 // -1 when absent
+	logical    tstruct
+	nested     bool
+	index      int // position among leaf columns
+}
+
+// File is a Parquet file held in memory.
+type File struct {
+	data      []byte
+	numRows   int64
+	columns   []Column
+	rowGroups []tstruct
+}
+
+// Open reads and parses the Parquet file at path.
+func Open(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Read parses a Parquet file from its raw bytes.
+func Read(data []byte) (*File, error) {
+	const magic = "PAR1"
+	if len(data) < 12 || string(data[:4]) != magic || string(data[len(data)-4:]) != magic {
+		return nil, fmt.Errorf("not a parquet file")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		return nil, fmt.Errorf("invalid footer length %d", footerLen)
+	}
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	cr := compactReader{buf: footer}
+	meta, err := cr.readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("reading file metadata: %w", err)
+	}
+
+	f := &File{data: data, numRows: meta.int(3)}
+	if err := f.parseSchema(meta.list(2)); err != nil {
+		return nil, err
+	}
+	for _, rg := range meta.list(4) {
+		if s, ok := rg.(tstruct); ok {
+			f.rowGroups = append(f.rowGroups, s)
+		}
+	}
+	return f, nil
+}
+
+func (f *File) parseSchema(elements []any) error {
+	if len(elements) == 0 {
+		return fmt.Errorf("empty schema")
+	}
+	schema := make([]tstruct, 0, len(elements))
+	for _, e := range elements {
+		s, ok := e.(tstruct)
+		if !ok {
+			return fmt.Errorf("malformed schema element")
+		}
+		schema = append(schema, s)
+	}
+
+	// Walk the schema tree depth-first; only leaves hold data, and only
+	// leaves directly under the root are flat columns we can read.
+	leaf := 0
+	pos := 1
+	var walk func(depth int) error
+	walk = func(depth int) error {
+		if pos >= len(schema) {
+			return fmt.Errorf("schema truncated")
+		}
+		el := schema[pos]
+		pos++
+		children := int(el.int(5))
+		if children > 0 {
+			for i := 0; i < children; i++ {
+				if err := walk(depth + 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		col := Column{
+			Name:       el.string(4),
+			Type:       Type(el.int(1)),
+			Optional:   el.int(3) == repetitionOptional,
+			typeLength: int(el.int(2)),
+			converted:  -1,
+			logical:    el.strct(10),
+			nested:     depth > 0 || el.int(3) > repetitionOptional,
+			index:      leaf,
+		}
+		if el.has(6) {
+			col.converted = el.int(6)
+		}
+		leaf++
+		f.columns = append(f.columns, col)
+		return nil
+	}
+	for i := 0; i < int(schema[0].int(5)); i++ {
+		if err := walk(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NumRows returns the number of rows in the file.
+func (f *File) NumRows() int64 { return f.numRows }
+
+// Columns returns the leaf columns of the schema in file order.
+func (f *File) Columns() []Column { return f.columns }
+
+// Column looks up a top-level column by name.
+func (f *File) Column(name string) (Column, bool) {
+	for _, c := range f.columns {
+		if c.Name == name && !c.nested {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// ReadColumn returns every value of the named column, one per row, with nil
+// for nulls. Values are mapped to Go types as follows: BOOLEAN to bool,
+// INT32/INT64 to int64, FLOAT/DOUBLE to float64, strings to string, dates and
+// timestamps (including INT96) to time.Time in UTC, and any other binary data
+// to []byte.
+func (f *File) ReadColumn(name string) ([]any, error) {
+	col, ok := f.Column(name)
+	if !ok {
+		return nil, fmt.Errorf("column %q not found", name)
+	}
+	values := make([]any, 0, f.numRows)
+	for i, rg := range f.rowGroups {
+		chunks := rg.list(1)
+		if col.index >= len(chunks) {
+			return nil, fmt.Errorf("row group %d: missing column chunk for %q", i, name)
+		}
+		chunk, _ := chunks[col.index].(tstruct)
+		v, err := f.readChunk(col, chunk.strct(3))
+		if err != nil {
+			return nil, fmt.Errorf("column %q, row group %d: %w", name, i, err)
+		}
+		values = append(values, v...)
+	}
+	return values, nil
+}
+
+func (f *File) readChunk(col Column, meta tstruct) ([]any, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("column chunk has no metadata")
+	}
+	codec := meta.int(4)
+	numValues := meta.int(5)
+	pos := meta.int(9)
+	if dictOffset := meta.int(11); meta.has(11) && dictOffset > 0 && dictOffset < pos {
+		pos = dictOffset
+	}
+
+	var dict []any
+	values := make([]any, 0, numValues)
+	for int64(len(values)) < numValues {
+		if pos < 0 || pos >= int64(len(f.data)) {
+			return nil, fmt.Errorf("page offset %d out of range", pos)
+		}
+		cr := compactReader{buf: f.data[pos:]}
+		header, err := cr.readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("reading page header: %w", err)
+		}
+		pos += int64(cr.pos)
+		size := header.int(3)
+		if size < 0 || pos+size > int64(len(f.data)) {
+			return nil, fmt.Errorf("page size %d out of range", size)
+		}
+		page := f.data[pos : pos+size]
+		pos += size
+
+		switch header.int(1) {
+		case pageDictionary:
+			data, err := decompress(codec, page, header.int(2))
+			if err != nil {
+				return nil, err
+			}
+			dh := header.strct(7)
+			raw, err := decodePlain(col, data, int(dh.int(1)))
+			if err != nil {
+				return nil, fmt.Errorf("dictionary page: %w", err)
+			}
+			dict = make([]any, len(raw))
+			for i, v := range raw {
+				dict[i] = col.convert(v)
+			}
+		case pageData:
+			data, err := decompress(codec, page, header.int(2))
+			if err != nil {
+				return nil, err
+			}
+			dh := header.strct(5)
+			v, err := decodeDataPage(col, data, nil, int(dh.int(1)), dh.int(2), dict)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v...)
+		case pageDataV2:
+			dh := header.strct(8)
+			repLen, defLen := dh.int(6), dh.int(5)
+			if repLen < 0 || defLen < 0 || repLen+defLen > int64(len(page)) {
+				return nil, fmt.Errorf("invalid level lengths in page header")
+			}
+			levels := page[repLen : repLen+defLen]
+			data := page[repLen+defLen:]
+			if dh.bool(7, true) {
+				if data, err = decompress(codec, data, header.int(2)-repLen-defLen); err != nil {
+					return nil, err
+				}
+			}
+			v, err := decodeDataPage(col, data, levels, int(dh.int(1)), dh.int(4), dict)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v...)
+		default:
+			// Index pages carry no values
+		}
+	}
+	return values, nil
+}
+
+func decompress(codec int64, data []byte, uncompressedSize int64) ([]byte, error) {
+	switch codec {
+	case codecUncompressed:
+		return data, nil
+	case codecSnappy:
+		return snappyDecode(data)
+	case codecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		out := bytes.NewBuffer(make([]byte, 0, max(uncompressedSize, 0)))
+		if _, err := io.Copy(out, zr); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+	name := fmt.Sprintf("codec %d", codec)
+	if codec >= 0 && int(codec) < len(codecNames) {
+		name = codecNames[codec]
+	}
+	return nil, fmt.Errorf("unsupported compression %s", name)
+}
+
+// decodeDataPage decodes one data page into per-row values. For v1 pages the
+// definition levels are at the front of data; v2 pages pass them separately.
+func decodeDataPage(col Column, data, levels []byte, numValues int, encoding int64, dict []any) ([]any, error) {
+	if col.nested {
+		return nil, fmt.Errorf("nested columns are not supported")
+	}
+
+	present := numValues
+	var defs []uint32
+	if col.Optional {
+		if levels == nil {
+			if len(data) < 4 {
+				return nil, fmt.Errorf("truncated definition levels")
+			}
+			n := int(binary.LittleEndian.Uint32(data))
+			if n > len(data)-4 {
+				return nil, fmt.Errorf("definition levels length %d exceeds page", n)
+			}
+			levels, data = data[4:4+n], data[4+n:]
+		}
+		var err error
+		if defs, err = decodeHybrid(levels, 1, numValues); err != nil {
+			return nil, fmt.Errorf("definition levels: %w", err)
+		}
+		present = 0
+		for _, d := range defs {
+			if d == 1 {
+				present++
+			}
+		}
+	}
+
+	var vals []any
+	switch encoding {
+	case encodingPlain:
+		raw, err := decodePlain(col, data, present)
+		if err != nil {
+			return nil, err
+		}
+		vals = make([]any, len(raw))
+		for i, v := range raw {
+			vals[i] = col.convert(v)
+		}
+	case encodingPlainDict, encodingRLEDict:
+		if dict == nil {
+			return nil, fmt.Errorf("dictionary-encoded page without a dictionary")
+		}
+		if len(data) < 1 {
+			return nil, fmt.Errorf("truncated dictionary indices")
+		}
+		idx, err := decodeHybrid(data[1:], int(data[0]), present)
+		if err != nil {
+			return nil, fmt.Errorf("dictionary indices: %w", err)
+		}
+		vals = make([]any, len(idx))
+		for i, id := range idx {
+			if int(id) >= len(dict) {
+				return nil, fmt.Errorf("dictionary index %d out of range", id)
+			}
+			vals[i] = dict[id]
+		}
+	case encodingRLE:
+		if col.Type != Boolean || len(data) < 4 {
+			return nil, fmt.Errorf("unsupported RLE data for %s column", col.Type)
+		}
+		bits, err := decodeHybrid(data[4:], 1, present)
+		if err != nil {
+			return nil, err
+		}
+		vals = make([]any, len(bits))
+		for i, b := range bits {
+			vals[i] = b == 1
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %d", encoding)
+	}
+
+	if defs == nil {
+		return vals, nil
+	}
+	out := make([]any, numValues)
+	next := 0
+	for i, d := range defs {
+		if d == 1 {
+			out[i] = vals[next]
+			next++
+		}
+	}
+	return out, nil
+}
+
+// decodePlain decodes n PLAIN-encoded values of the column's physical type.
+func decodePlain(col Column, data []byte, n int) ([]any, error) {
+	out := make([]any, 0, n)
+	fixed := func(size int) ([]byte, error) {
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated %s value", col.Type)
+		}
+		b := data[:size]
+		data = data[size:]
+		return b, nil
+	}
+
+	if col.Type == Boolean {
+		if len(data)*8 < n {
+			return nil, fmt.Errorf("truncated BOOLEAN values")
+		}
+		for i := 0; i < n; i++ {
+			out = append(out, data[i/8]>>(i%8)&1 == 1)
+		}
+		return out, nil
+	}
+
+	for i := 0; i < n; i++ {
+		var v any
+		switch col.Type {
+		case Int32:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			v = int64(int32(binary.LittleEndian.Uint32(b)))
+		case Int64:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, err
+			}
+			v = int64(binary.LittleEndian.Uint64(b))
+		case Int96:
+			b, err := fixed(12)
+			if err != nil {
+				return nil, err
+			}
+			v = [12]byte(b)
+		case Float:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			v = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case Double:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, err
+			}
+			v = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case ByteArray:
+			lb, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			b, err := fixed(int(binary.LittleEndian.Uint32(lb)))
+			if err != nil {
+				return nil, err
+			}
+			v = b
+		case FixedLenByteArray:
+			b, err := fixed(col.typeLength)
+			if err != nil {
+				return nil, err
+			}
+			v = b
+		default:
+			return nil, fmt.Errorf("unsupported physical type %s", col.Type)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// convert maps a raw physical value to the Go type documented on ReadColumn.
+func (c Column) convert(v any) any {
+	switch x := v.(type) {
+	case [12]byte:
+		nanos := int64(binary.LittleEndian.Uint64(x[:8]))
+		julian := int64(binary.LittleEndian.Uint32(x[8:]))
+		const unixEpochJulian = 2440588
+		return time.Unix((julian-unixEpochJulian)*86400, nanos).UTC()
+	case int64:
+		switch {
+		case c.converted == convertedDate || c.logical.has(6):
+			return time.Unix(x*86400, 0).UTC()
+		case c.converted == convertedTimestampMillis:
+			return time.UnixMilli(x).UTC()
+		case c.converted == convertedTimestampMicros:
+			return time.UnixMicro(x).UTC()
+		case c.logical.has(8):
+			unit := c.logical.strct(8).strct(2)
+			switch {
+			case unit.has(1):
+				return time.UnixMilli(x).UTC()
+			case unit.has(2):
+				return time.UnixMicro(x).UTC()
+			case unit.has(3):
+				return time.Unix(0, x).UTC()
+			}
+		}
+		return x
+	case []byte:
+		if c.Type == ByteArray && (c.converted == convertedUTF8 || c.converted == convertedEnum ||
+			c.converted == convertedJSON || c.logical.has(1) || c.logical.has(4) || c.logical.has(12)) {
+			return string(x)
+		}
+		return x
+	}
+	return v
+}
+
+// decodeHybrid decodes n values of the RLE/bit-packed hybrid encoding used for
+// levels and dictionary indices.
+func decodeHybrid(data []byte, bitWidth, n int) ([]uint32, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, fmt.Errorf("invalid bit width %d", bitWidth)
+	}
+	out := make([]uint32, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	for len(out) < n {
+		header, hn := binary.Uvarint(data)
+		if hn <= 0 {
+			return nil, fmt.Errorf("truncated run header")
+		}
+		data = data[hn:]
+		if header&1 == 0 { // RLE run
+			count := int(header >> 1)
+			if len(data) < byteWidth {
+				return nil, fmt.Errorf("truncated RLE run")
+			}
+			var v uint32
+			for i := byteWidth - 1; i >= 0; i-- {
+				v = v<<8 | uint32(data[i])
+			}
+			data = data[byteWidth:]
+			for i := 0; i < count && len(out) < n; i++ {
+				out = append(out, v)
+			}
+			continue
+		}
+		// Bit-packed run of groups of eight values, least significant bit first
+		count := int(header>>1) * 8
+		size := count * bitWidth / 8
+		if len(data) < size {
+			size = len(data) // writers may truncate the final run
+		}
+		var acc uint64
+		accBits := 0
+		packed := data[:size]
+		data = data[size:]
+		mask := uint64(1)<<bitWidth - 1
+		for i := 0; i < count && len(out) < n; i++ {
+			for accBits < bitWidth {
+				if len(packed) == 0 {
+					return nil, fmt.Errorf("truncated bit-packed run")
+				}
+				acc |= uint64(packed[0]) << accBits
+				packed = packed[1:]
+				accBits += 8
+			}
+			out = append(out, uint32(acc&mask))
+			acc >>= bitWidth
+			accBits -= bitWidth
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package parquet
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadGames(t *testing.T) {
+	f, err := Open("testdata/games.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumRows() != 4 {
+		t.Fatalf("NumRows() = %d, want 4", f.NumRows())
+	}
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	tests := []struct {
+		column string
+		want   []any
+	}{
+		{"date", []any{day("2017-08-11"), day("2017-08-12"), day("2017-08-12"), day("2018-05-13")}},
+		{"home", []any{"Arsenal", "Watford", "Arsenal", "Watford"}},
+		{"away", []any{"Leicester City", "Liverpool", nil, "Man Utd"}},
+		{"gh", []any{int64(4), int64(3), int64(1), nil}},
+	}
+	for _, tt := range tests {
+		got, err := f.ReadColumn(tt.column)
+		if err != nil {
+			t.Errorf("ReadColumn(%q): %v", tt.column, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadColumn(%q) = %v, want %v", tt.column, got, tt.want)
+		}
+	}
+
+	ga, err := f.ReadColumn("ga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ga) != 4 || ga[0] != 3.0 || ga[2] != 0.0 || !math.IsNaN(ga[3].(float64)) {
+		t.Errorf("ReadColumn(\"ga\") = %v, want [3 3 0 NaN]", ga)
+	}
+	if _, err := f.ReadColumn("gameid"); err == nil {
+		t.Error("ReadColumn(\"gameid\") succeeded on a file without that column")
+	}
+}
+
+func TestReadRejectsNonParquet(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("PAR1PAR1"), []byte("PAR1\x00\x00\x00\x00\xff\x00\x00\x00PAR1")} {
+		if _, err := Read(data); err == nil {
+			t.Errorf("Read(%q) succeeded", data)
+		}
+	}
+}
+
+func TestDecodeHybrid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		bitWidth int
+		n        int
+		want     []uint32
+	}{
+		{"rle run", []byte{4 << 1, 1}, 1, 4, []uint32{1, 1, 1, 1}},
+		{"rle run cut at n", []byte{10 << 1, 7}, 3, 3, []uint32{7, 7, 7}},
+		{"rle two-byte value", []byte{2 << 1, 0x34, 0x12}, 13, 2, []uint32{0x1234, 0x1234}},
+		{"bit-packed width 1", []byte{1<<1 | 1, 0b10110010}, 1, 8, []uint32{0, 1, 0, 0, 1, 1, 0, 1}},
+		// 0..7 in three bits each, least significant bit first
+		{"bit-packed width 3", []byte{1<<1 | 1, 0x88, 0xc6, 0xfa}, 3, 8, []uint32{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"bit-packed then rle", []byte{1<<1 | 1, 0b11, 3 << 1, 1}, 1, 5, []uint32{1, 1, 0, 0, 0}},
+		{"truncated final run", []byte{1<<1 | 1, 0b101}, 2, 2, []uint32{1, 1}},
+		{"zero width", []byte{5 << 1}, 0, 5, []uint32{0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		got, err := decodeHybrid(tt.data, tt.bitWidth, tt.n)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, data := range [][]byte{{}, {4 << 1}, {2<<1 | 1, 0xff}} {
+		if got, err := decodeHybrid(data, 8, 16); err == nil {
+			t.Errorf("decodeHybrid(%v) = %v, want an error", data, got)
+		}
+	}
+}
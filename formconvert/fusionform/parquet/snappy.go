@@ -0,0 +1,79 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// snappyDecode decodes a raw (unframed) Snappy block, the codec pandas and
+// pyarrow use by default when writing Parquet.
+func snappyDecode(src []byte) ([]byte, error) {
+	n, hdr := binary.Uvarint(src)
+	if hdr <= 0 || n > 1<<31 {
+		return nil, fmt.Errorf("snappy: invalid length header")
+	}
+	dst := make([]byte, 0, n)
+	src = src[hdr:]
+
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 0x03 {
+		case 0x00: // literal
+			length = int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				extra := length - 59
+				if len(src) < extra {
+					return nil, fmt.Errorf("snappy: truncated literal length")
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			length++
+			if length > len(src) {
+				return nil, fmt.Errorf("snappy: truncated literal")
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 0x01: // copy, 1-byte offset
+			if len(src) < 2 {
+				return nil, fmt.Errorf("snappy: truncated copy")
+			}
+			length = 4 + int(tag>>2&0x07)
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 0x02: // copy, 2-byte offset
+			if len(src) < 3 {
+				return nil, fmt.Errorf("snappy: truncated copy")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 0x03: // copy, 4-byte offset
+			if len(src) < 5 {
+				return nil, fmt.Errorf("snappy: truncated copy")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, fmt.Errorf("snappy: invalid copy offset %d", offset)
+		}
+		// Copies may overlap their own output, so go byte by byte
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if uint64(len(dst)) != n {
+		return nil, fmt.Errorf("snappy: decoded %d bytes, header says %d", len(dst), n)
+	}
+	return dst, nil
+}
@@ -0,0 +1,53 @@
+package parquet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSnappyDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"literal", []byte{5, 4 << 2, 'h', 'e', 'l', 'l', 'o'}, "hello"},
+		// "abc", then 1-byte-offset copies: length 4 + 1 at offset 3
+		{"copy 1-byte offset", []byte{8, 2 << 2, 'a', 'b', 'c', 1<<2 | 1, 3}, "abcabcab"},
+		// A copy longer than its offset repeats its own output
+		{"overlapping copy", []byte{9, 0, 'x', 4<<2 | 1, 1}, "xxxxxxxxx"},
+		{"copy 2-byte offset", []byte{6, 2 << 2, 'a', 'b', 'c', 2<<2 | 2, 3, 0}, "abcabc"},
+		{"copy 4-byte offset", []byte{5, 1 << 2, 'a', 'b', 2<<2 | 3, 2, 0, 0, 0}, "ababa"},
+		// The top three bits of a 1-byte-offset tag extend the offset past 255
+		{"copy 11-bit offset", append(append([]byte{0x84, 0x02, 60 << 2, 255}, bytes.Repeat([]byte{'a'}, 255)...), 'b', 0x20|1, 0), strings.Repeat("a", 255) + "b" + "aaaa"},
+		{"long literal", append([]byte{70, 60 << 2, 69}, bytes.Repeat([]byte{'z'}, 70)...), strings.Repeat("z", 70)},
+	}
+	for _, tt := range tests {
+		got, err := snappyDecode(tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	bad := []struct {
+		name string
+		src  []byte
+	}{
+		{"no header", nil},
+		{"offset zero", []byte{5, 0, 'a', 1, 0}},
+		{"offset past start", []byte{5, 0, 'a', 1, 2}},
+		{"truncated literal", []byte{5, 4 << 2, 'h'}},
+		{"truncated copy", []byte{5, 0, 'a', 2}},
+		{"length mismatch", []byte{3, 0, 'a'}},
+	}
+	for _, tt := range bad {
+		if got, err := snappyDecode(tt.src); err == nil {
+			t.Errorf("%s: got %q, want an error", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+//go:build ignore
+
+// makegames writes games.parquet, a four-row stand-in for the results file
+// that exercises each code path of the reader: a timestamp column in a plain
+// v1 page, a dictionary-encoded SNAPPY column, an optional GZIP column in a
+// v2 page, an optional integer column with v1 definition levels and a double
+// column holding NaN. Run it from this directory with
+//
+//	go run makegames.go
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"math"
+	"os"
+	"time"
+)
+
+// compact writes the Thrift compact protocol.
+type compact struct {
+	bytes.Buffer
+	last []int16
+}
+
+func (w *compact) uvarint(v uint64) { w.Write(binary.AppendUvarint(nil, v)) }
+func (w *compact) varint(v int64)   { w.uvarint(uint64(v<<1 ^ v>>63)) }
+
+func (w *compact) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		w.WriteByte(byte(d)<<4 | typ)
+	} else {
+		w.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+func (w *compact) begin()                { w.last = append(w.last, 0) }
+func (w *compact) end()                  { w.WriteByte(0); w.last = w.last[:len(w.last)-1] }
+func (w *compact) i32(id int16, v int64) { w.field(id, 5); w.varint(v) }
+func (w *compact) i64(id int16, v int64) { w.field(id, 6); w.varint(v) }
+func (w *compact) str(id int16, s string) {
+	w.field(id, 8)
+	w.uvarint(uint64(len(s)))
+	w.WriteString(s)
+}
+func (w *compact) boolean(id int16, v bool) {
+	if v {
+		w.field(id, 1)
+	} else {
+		w.field(id, 2)
+	}
+}
+func (w *compact) list(id int16, elem byte, n int) {
+	w.field(id, 9)
+	if n < 15 {
+		w.WriteByte(byte(n)<<4 | elem)
+	} else {
+		w.WriteByte(0xf0 | elem)
+		w.uvarint(uint64(n))
+	}
+}
+func (w *compact) strct(id int16) { w.field(id, 12); w.begin() }
+
+type page struct {
+	header func(w *compact, compressed, uncompressed int)
+	data   []byte // uncompressed body
+	levels []byte // v2 definition levels, stored uncompressed in front
+	codec  int
+}
+
+type column struct {
+	name       string
+	typ, rep   int64
+	converted  int64 // -1 for none
+	codec      int
+	dictionary *page
+	data       page
+	values     int
+}
+
+func compress(codec int, b []byte) []byte {
+	switch codec {
+	case 1: // SNAPPY, as literals only
+		out := binary.AppendUvarint(nil, uint64(len(b)))
+		out = append(out, byte(len(b)-1)<<2)
+		return append(out, b...)
+	case 2:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(b)
+		zw.Close()
+		return buf.Bytes()
+	}
+	return b
+}
+
+func plainStrings(ss ...string) []byte {
+	out := []byte{}
+	for _, s := range ss {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(s)))
+		out = append(out, s...)
+	}
+	return out
+}
+
+func withLevels(levels, values []byte) []byte {
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))), append(levels, values...)...)
+}
+
+func dataPageV1(n, encoding int64) func(*compact, int, int) {
+	return func(w *compact, compressed, uncompressed int) {
+		w.i32(1, 0)
+		w.i32(2, int64(uncompressed))
+		w.i32(3, int64(compressed))
+		w.strct(5)
+		w.i32(1, n)
+		w.i32(2, encoding)
+		w.i32(3, 3)
+		w.i32(4, 3)
+		w.end()
+	}
+}
+
+func main() {
+	day := func(s string) int64 {
+		t, _ := time.Parse("2006-01-02", s)
+		return t.UnixMilli()
+	}
+	dates := []byte{}
+	for _, d := range []string{"2017-08-11", "2017-08-12", "2017-08-12", "2018-05-13"} {
+		dates = binary.LittleEndian.AppendUint64(dates, uint64(day(d)))
+	}
+	// Definition levels 1,1,1,0 as one bit-packed group of eight
+	defs := []byte{3, 0b0111}
+	away := plainStrings("Leicester City", "Liverpool", "Man Utd")
+	awayLevels := []byte{3, 0b1011} // the third away team is missing
+	goals := binary.LittleEndian.AppendUint64(nil, 4)
+	goals = binary.LittleEndian.AppendUint64(goals, 3)
+	goals = binary.LittleEndian.AppendUint64(goals, 1)
+	against := []byte{}
+	for _, v := range []float64{3, 3, 0, math.NaN()} {
+		against = binary.LittleEndian.AppendUint64(against, math.Float64bits(v))
+	}
+
+	columns := []column{
+		{name: "date", typ: 2, converted: 9, values: 4,
+			data: page{header: dataPageV1(4, 0), data: dates}},
+		{name: "home", typ: 6, converted: 0, codec: 1, values: 4,
+			dictionary: &page{data: plainStrings("Arsenal", "Watford"), header: func(w *compact, c, u int) {
+				w.i32(1, 2)
+				w.i32(2, int64(u))
+				w.i32(3, int64(c))
+				w.strct(7)
+				w.i32(1, 2)
+				w.i32(2, 0)
+				w.end()
+			}},
+			// Bit width 1, indices 0,1,0,1 as one bit-packed group
+			data: page{header: dataPageV1(4, 8), data: []byte{1, 3, 0b1010}}},
+		{name: "away", typ: 6, rep: 1, converted: 0, codec: 2, values: 4,
+			data: page{data: away, levels: awayLevels, header: func(w *compact, c, u int) {
+				w.i32(1, 3)
+				w.i32(2, int64(u))
+				w.i32(3, int64(c))
+				w.strct(8)
+				w.i32(1, 4)
+				w.i32(2, 1)
+				w.i32(3, 4)
+				w.i32(4, 0)
+				w.i32(5, int64(len(awayLevels)))
+				w.i32(6, 0)
+				w.boolean(7, true)
+				w.end()
+			}}},
+		{name: "gh", typ: 2, rep: 1, converted: -1, values: 4,
+			data: page{header: dataPageV1(4, 0), data: withLevels(defs, goals)}},
+		{name: "ga", typ: 5, converted: -1, values: 4,
+			data: page{header: dataPageV1(4, 0), data: against}},
+	}
+
+	file := bytes.NewBufferString("PAR1")
+	writePage := func(p *page, codec int) {
+		body := append(append([]byte{}, p.levels...), compress(codec, p.data)...)
+		w := &compact{}
+		w.begin()
+		p.header(w, len(body), len(p.levels)+len(p.data))
+		w.end()
+		file.Write(w.Bytes())
+		file.Write(body)
+	}
+	type offsets struct{ dict, data, end int64 }
+	chunks := make([]offsets, len(columns))
+	for i := range columns {
+		c := &columns[i]
+		if c.dictionary != nil {
+			chunks[i].dict = int64(file.Len())
+			writePage(c.dictionary, c.codec)
+		}
+		chunks[i].data = int64(file.Len())
+		writePage(&c.data, c.codec)
+		chunks[i].end = int64(file.Len())
+	}
+
+	w := &compact{}
+	w.begin()
+	w.i32(1, 1)
+	w.list(2, 12, len(columns)+1)
+	w.begin()
+	w.str(4, "schema")
+	w.i32(5, int64(len(columns)))
+	w.end()
+	for _, c := range columns {
+		w.begin()
+		w.i32(1, c.typ)
+		w.i32(3, c.rep)
+		w.str(4, c.name)
+		if c.converted >= 0 {
+			w.i32(6, c.converted)
+		}
+		w.end()
+	}
+	w.i64(3, 4)
+	w.list(4, 12, 1)
+	w.begin()
+	w.list(1, 12, len(columns))
+	for i, c := range columns {
+		start := chunks[i].data
+		if c.dictionary != nil {
+			start = chunks[i].dict
+		}
+		w.begin()
+		w.i64(2, start)
+		w.strct(3)
+		w.i32(1, c.typ)
+		w.list(2, 5, 1)
+		w.varint(0)
+		w.list(3, 8, 1)
+		w.uvarint(uint64(len(c.name)))
+		w.WriteString(c.name)
+		w.i32(4, int64(c.codec))
+		w.i64(5, int64(c.values))
+		w.i64(6, chunks[i].end-start)
+		w.i64(7, chunks[i].end-start)
+		w.i64(9, chunks[i].data)
+		if c.dictionary != nil {
+			w.i64(11, chunks[i].dict)
+		}
+		w.end()
+		w.end()
+	}
+	w.i64(2, int64(file.Len()))
+	w.i64(3, 4)
+	w.end()
+	w.end()
+
+	file.Write(w.Bytes())
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(w.Len())))
+	file.WriteString("PAR1")
+	if err := os.WriteFile("games.parquet", file.Bytes(), 0o644); err != nil {
+		panic(err)
+	}
+}
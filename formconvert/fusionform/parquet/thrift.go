@@ -0,0 +1,235 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Thrift compact protocol type ids
+const (
+	tStop            = 0
+	tBoolTrue        = 1
+	tBoolFalse       = 2
+	tByte            = 3
+	tI16             = 4
+	tI32             = 5
+	tI64             = 6
+	tDouble          = 7
+	tBinary          = 8
+	tList            = 9
+	tSet             = 10
+	tMap             = 11
+	tStruct          = 12
+	maxThriftNesting = 64
+)
+
+// tstruct is a decoded Thrift struct keyed by field id. Parquet metadata is
+// small, so decoding generically and picking fields afterwards keeps the
+// reader independent of the exact parquet.thrift revision that wrote the file.
+type tstruct map[int16]any
+
+// compactReader decodes the Thrift compact protocol used by Parquet footers
+// and page headers.
+type compactReader struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+func (r *compactReader) readByte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, fmt.Errorf("thrift: unexpected end of data")
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *compactReader) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("thrift: invalid varint at offset %d", r.pos)
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *compactReader) readVarint() (int64, error) {
+	u, err := r.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil // zigzag
+}
+
+func (r *compactReader) readBinary() ([]byte, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, fmt.Errorf("thrift: binary length %d exceeds data", n)
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *compactReader) readStruct() (tstruct, error) {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > maxThriftNesting {
+		return nil, fmt.Errorf("thrift: structure nested too deeply")
+	}
+
+	s := tstruct{}
+	var lastID int16
+	for {
+		header, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		typ := header & 0x0f
+		if typ == tStop {
+			return s, nil
+		}
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := r.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		lastID = id
+
+		var v any
+		switch typ {
+		case tBoolTrue:
+			v = true
+		case tBoolFalse:
+			v = false
+		default:
+			v, err = r.readValue(typ)
+			if err != nil {
+				return nil, err
+			}
+		}
+		s[id] = v
+	}
+}
+
+func (r *compactReader) readValue(typ byte) (any, error) {
+	switch typ {
+	case tBoolTrue, tBoolFalse:
+		// Booleans inside collections are a single byte each
+		b, err := r.readByte()
+		return b == tBoolTrue, err
+	case tByte:
+		b, err := r.readByte()
+		return int64(int8(b)), err
+	case tI16, tI32, tI64:
+		return r.readVarint()
+	case tDouble:
+		if len(r.buf)-r.pos < 8 {
+			return nil, fmt.Errorf("thrift: unexpected end of data")
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case tBinary:
+		return r.readBinary()
+	case tList, tSet:
+		return r.readList()
+	case tMap:
+		return r.readMap()
+	case tStruct:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("thrift: unknown compact type %d", typ)
+}
+
+func (r *compactReader) readList() ([]any, error) {
+	header, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(header >> 4)
+	if size == 15 {
+		if size, err = r.readUvarint(); err != nil {
+			return nil, err
+		}
+	}
+	if size > uint64(len(r.buf)-r.pos) { // every element takes at least one byte
+		return nil, fmt.Errorf("thrift: list size %d exceeds data", size)
+	}
+	elemType := header & 0x0f
+	list := make([]any, 0, size)
+	for i := uint64(0); i < size; i++ {
+		v, err := r.readValue(elemType)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// readMap skips over a map; Parquet only uses maps in fields the reader ignores.
+func (r *compactReader) readMap() (any, error) {
+	size, err := r.readUvarint()
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	if size > uint64(len(r.buf)-r.pos) {
+		return nil, fmt.Errorf("thrift: map size %d exceeds data", size)
+	}
+	types, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < size; i++ {
+		if _, err := r.readValue(types >> 4); err != nil {
+			return nil, err
+		}
+		if _, err := r.readValue(types & 0x0f); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Field accessors returning zero values when a field is absent or mistyped.
+
+func (s tstruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s tstruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s tstruct) bool(id int16, def bool) bool {
+	if v, ok := s[id].(bool); ok {
+		return v
+	}
+	return def
+}
+
+func (s tstruct) string(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s tstruct) strct(id int16) tstruct {
+	v, _ := s[id].(tstruct)
+	return v
+}
+
+func (s tstruct) list(id int16) []any {
+	v, _ := s[id].([]any)
+	return v
+}
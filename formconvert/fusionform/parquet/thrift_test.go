@@ -0,0 +1,73 @@
+package parquet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadVarint(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want int64
+	}{
+		{[]byte{0x00}, 0},
+		{[]byte{0x01}, -1},
+		{[]byte{0x02}, 1},
+		{[]byte{0x03}, -2},
+		{[]byte{0x7f}, -64},
+		{[]byte{0x80, 0x01}, 64},
+		{[]byte{0xac, 0x02}, 150},
+		{[]byte{0xfe, 0xff, 0xff, 0xff, 0x0f}, 2147483647},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, -2147483648},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, -9223372036854775808},
+	}
+	for _, tt := range tests {
+		r := compactReader{buf: tt.data}
+		got, err := r.readVarint()
+		if err != nil || got != tt.want || r.pos != len(tt.data) {
+			t.Errorf("readVarint(% x) = %d, %v at %d, want %d at %d", tt.data, got, err, r.pos, tt.want, len(tt.data))
+		}
+	}
+
+	for _, data := range [][]byte{{}, {0x80}, {0xff, 0xff}} {
+		r := compactReader{buf: data}
+		if got, err := r.readVarint(); err == nil {
+			t.Errorf("readVarint(% x) = %d, want an error", data, got)
+		}
+	}
+}
+
+func TestReadStruct(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want tstruct
+	}{
+		{"empty", []byte{0x00}, tstruct{}},
+		{"field deltas", []byte{0x15, 0x04, 0x26, 0x03, 0x00}, tstruct{1: int64(2), 3: int64(-2)}},
+		{"long field id", []byte{0x05, 0x28, 0x02, 0x00}, tstruct{20: int64(1)}},
+		{"booleans", []byte{0x11, 0x12, 0x00}, tstruct{1: true, 2: false}},
+		{"binary", []byte{0x18, 0x02, 'g', 'h', 0x00}, tstruct{1: []byte("gh")}},
+		{"list", []byte{0x19, 0x25, 0x02, 0x04, 0x00}, tstruct{1: []any{int64(1), int64(2)}}},
+		{"nested struct", []byte{0x1c, 0x15, 0x06, 0x00, 0x00}, tstruct{1: tstruct{1: int64(3)}}},
+		{"map skipped", []byte{0x1b, 0x01, 0x55, 0x02, 0x04, 0x15, 0x02, 0x00}, tstruct{1: nil, 2: int64(1)}},
+	}
+	for _, tt := range tests {
+		r := compactReader{buf: tt.data}
+		got, err := r.readStruct()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, data := range [][]byte{{}, {0x15}, {0x18, 0x05, 'a'}, {0x19, 0xf5, 0xff, 0x01}} {
+		r := compactReader{buf: data}
+		if got, err := r.readStruct(); err == nil {
+			t.Errorf("readStruct(% x) = %v, want an error", data, got)
+		}
+	}
+}
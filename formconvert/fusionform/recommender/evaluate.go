@@ -0,0 +1,361 @@
+package recommender
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Predictor gives outcome probabilities for our formation against the
+// opponent's. *Model is a Predictor; so are the baselines below.
+type Predictor interface {
+	Predict(opp, our string) (Probabilities, error)
+}
+
+// Fitter builds a predictor from training samples.
+type Fitter struct {
+	Name string
+	Fit  func(train []Sample) (Predictor, error)
+}
+
+// ModelFitter trains a model with cfg on each training split.
+func ModelFitter(cfg Config) Fitter {
+	return Fitter{Name: "model", Fit: func(train []Sample) (Predictor, error) {
+		return Train(train, cfg, nil)
+	}}
+}
+
+// MostFrequentFitter is the most-frequent-formation baseline: every matchup
+// is predicted with the outcome rates of the formation played most often in
+// the training data, so formations carry no information.
+var MostFrequentFitter = Fitter{Name: "most-frequent", Fit: func(train []Sample) (Predictor, error) {
+	counts := map[string]int{}
+	for _, s := range train {
+		counts[s.Our]++
+	}
+	best := ""
+	for f, n := range counts {
+		if best == "" || n > counts[best] || n == counts[best] && f < best {
+			best = f
+		}
+	}
+	rates := newTally()
+	for _, s := range train {
+		if s.Our == best {
+			rates.add(s.Outcome)
+		}
+	}
+	return constant(rates.smoothed(Probabilities{1. / 3, 1. / 3, 1. / 3}, 1)), nil
+}}
+
+// MatchupFitter is the empirical matchup table: the observed outcome rates of
+// our formation against the opponent's, shrunk towards our formation's overall
+// rates and then the league-wide rates when a matchup is rarely seen.
+var MatchupFitter = Fitter{Name: "matchup", Fit: func(train []Sample) (Predictor, error) {
+	t := &matchupTable{overall: newTally(), byOur: map[string]*tally{}, byPair: map[[2]string]*tally{}}
+	for _, s := range train {
+		t.overall.add(s.Outcome)
+		if t.byOur[s.Our] == nil {
+			t.byOur[s.Our] = newTally()
+		}
+		t.byOur[s.Our].add(s.Outcome)
+		key := [2]string{s.Our, s.Opp}
+		if t.byPair[key] == nil {
+			t.byPair[key] = newTally()
+		}
+		t.byPair[key].add(s.Outcome)
+	}
+	return t, nil
+}}
+
+type constant Probabilities
+
+func (c constant) Predict(opp, our string) (Probabilities, error) { return Probabilities(c), nil }
+
+type matchupTable struct {
+	overall *tally
+	byOur   map[string]*tally
+	byPair  map[[2]string]*tally
+}
+
+// matchupPrior is how many pseudo-observations the backoff rates are worth.
+const matchupPrior = 5
+
+func (t *matchupTable) Predict(opp, our string) (Probabilities, error) {
+	p := t.overall.smoothed(Probabilities{1. / 3, 1. / 3, 1. / 3}, 1)
+	if ours, ok := t.byOur[our]; ok {
+		p = ours.smoothed(p, matchupPrior)
+	}
+	if pair, ok := t.byPair[[2]string{our, opp}]; ok {
+		p = pair.smoothed(p, matchupPrior)
+	}
+	return p, nil
+}
+
+type tally struct {
+	n      int
+	counts [numOutcomes]int
+}
+
+func newTally() *tally { return &tally{} }
+
+func (t *tally) add(o Outcome) {
+	t.n++
+	t.counts[o]++
+}
+
+// smoothed returns the observed rates with weight pseudo-observations drawn
+// from prior.
+func (t *tally) smoothed(prior Probabilities, weight float64) Probabilities {
+	var p Probabilities
+	for o := range p {
+		p[o] = (float64(t.counts[o]) + weight*prior[o]) / (float64(t.n) + weight)
+	}
+	return p
+}
+
+// Fold is one train/test split.
+type Fold struct {
+	Name  string
+	Train []Sample
+	Test  []Sample
+}
+
+// KFold splits samples into k folds. Both perspectives of a game always land
+// in the same fold so the test set never contains the mirror of a training
+// row.
+func KFold(samples []Sample, k int, seed int64) ([]Fold, error) {
+	games := []int64{}
+	seen := map[int64]bool{}
+	for _, s := range samples {
+		if !seen[s.GameID] {
+			seen[s.GameID] = true
+			games = append(games, s.GameID)
+		}
+	}
+	if k < 2 || k > len(games) {
+		return nil, fmt.Errorf("need between 2 and %d folds, got %d", len(games), k)
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(len(games), func(i, j int) { games[i], games[j] = games[j], games[i] })
+	foldOf := map[int64]int{}
+	for i, g := range games {
+		foldOf[g] = i % k
+	}
+
+	folds := make([]Fold, k)
+	for i := range folds {
+		folds[i].Name = fmt.Sprintf("fold %d/%d", i+1, k)
+	}
+	for _, s := range samples {
+		f := foldOf[s.GameID]
+		for i := range folds {
+			if i == f {
+				folds[i].Test = append(folds[i].Test, s)
+			} else {
+				folds[i].Train = append(folds[i].Train, s)
+			}
+		}
+	}
+	return folds, nil
+}
+
+// Holdout splits samples into a training set and a test set of about frac of
+// the games, keeping both perspectives of a game on the same side as KFold
+// does.
+func Holdout(samples []Sample, frac float64, seed int64) (train, test []Sample) {
+	games := []int64{}
+	seen := map[int64]bool{}
+	for _, s := range samples {
+		if !seen[s.GameID] {
+			seen[s.GameID] = true
+			games = append(games, s.GameID)
+		}
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(len(games), func(i, j int) { games[i], games[j] = games[j], games[i] })
+	held := map[int64]bool{}
+	for _, g := range games[int(float64(len(games))*(1-frac)):] {
+		held[g] = true
+	}
+	for _, s := range samples {
+		if held[s.GameID] {
+			test = append(test, s)
+		} else {
+			train = append(train, s)
+		}
+	}
+	return train, test
+}
+
+// SeasonHoldout makes one fold per season, testing on that season after
+// training on all the others.
+func SeasonHoldout(samples []Sample) ([]Fold, error) {
+	seasons := []string{}
+	seen := map[string]bool{}
+	for _, s := range samples {
+		if !seen[s.Season] {
+			seen[s.Season] = true
+			seasons = append(seasons, s.Season)
+		}
+	}
+	if len(seasons) < 2 {
+		return nil, fmt.Errorf("season holdout needs at least two seasons, found %d", len(seasons))
+	}
+	sort.Strings(seasons)
+	folds := make([]Fold, 0, len(seasons))
+	for _, season := range seasons {
+		f := Fold{Name: "season " + season}
+		for _, s := range samples {
+			if s.Season == season {
+				f.Test = append(f.Test, s)
+			} else {
+				f.Train = append(f.Train, s)
+			}
+		}
+		folds = append(folds, f)
+	}
+	return folds, nil
+}
+
+// calibrationBins is the number of probability bins used for the expected
+// calibration error.
+const calibrationBins = 10
+
+// Metrics accumulates test predictions.
+type Metrics struct {
+	N       int
+	Correct int
+	LogLoss float64 // summed; see MeanLogLoss
+
+	predicted [numOutcomes]float64
+	observed  [numOutcomes]int
+	binP      [numOutcomes][calibrationBins]float64
+	binHits   [numOutcomes][calibrationBins]int
+	binN      [numOutcomes][calibrationBins]int
+}
+
+func (m *Metrics) add(p Probabilities, actual Outcome) {
+	m.N++
+	best := Loss
+	for o := range p {
+		if p[o] > p[best] {
+			best = Outcome(o)
+		}
+		m.predicted[o] += p[o]
+		b := min(int(p[o]*calibrationBins), calibrationBins-1)
+		m.binP[o][b] += p[o]
+		m.binN[o][b]++
+		if Outcome(o) == actual {
+			m.binHits[o][b]++
+		}
+	}
+	if best == actual {
+		m.Correct++
+	}
+	m.observed[actual]++
+	m.LogLoss -= math.Log(math.Max(p[actual], 1e-12))
+}
+
+// Accuracy is the share of samples whose most likely outcome happened.
+func (m *Metrics) Accuracy() float64 { return float64(m.Correct) / float64(m.N) }
+
+// MeanLogLoss is the mean cross-entropy.
+func (m *Metrics) MeanLogLoss() float64 { return m.LogLoss / float64(m.N) }
+
+// MeanPredicted is the average predicted probability of each outcome.
+func (m *Metrics) MeanPredicted() Probabilities {
+	var p Probabilities
+	for o := range p {
+		p[o] = m.predicted[o] / float64(m.N)
+	}
+	return p
+}
+
+// Observed is the observed frequency of each outcome.
+func (m *Metrics) Observed() Probabilities {
+	var p Probabilities
+	for o := range p {
+		p[o] = float64(m.observed[o]) / float64(m.N)
+	}
+	return p
+}
+
+// CalibrationGap is the mean absolute difference between the average
+// predicted and the observed rate of each outcome. It is the calibration
+// measure reported per formation, where samples are too few to bin.
+func (m *Metrics) CalibrationGap() float64 {
+	pred, obs := m.MeanPredicted(), m.Observed()
+	gap := 0.0
+	for o := range pred {
+		gap += math.Abs(pred[o] - obs[o])
+	}
+	return gap / float64(numOutcomes)
+}
+
+// ECE is the expected calibration error over ten probability bins, averaged
+// over the three outcomes.
+func (m *Metrics) ECE() float64 {
+	ece := 0.0
+	for o := 0; o < int(numOutcomes); o++ {
+		for b := 0; b < calibrationBins; b++ {
+			if n := m.binN[o][b]; n > 0 {
+				gap := math.Abs(m.binP[o][b]/float64(n) - float64(m.binHits[o][b])/float64(n))
+				ece += float64(n) / float64(m.N) * gap
+			}
+		}
+	}
+	return ece / float64(numOutcomes)
+}
+
+// Report is the evaluation of one predictor across all folds.
+type Report struct {
+	Name        string
+	Overall     *Metrics
+	ByFormation map[string]*Metrics // keyed by our formation
+}
+
+// Formations returns the formation classes in the report, most samples first.
+func (r *Report) Formations() []string {
+	names := make([]string, 0, len(r.ByFormation))
+	for f := range r.ByFormation {
+		names = append(names, f)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := r.ByFormation[names[i]], r.ByFormation[names[j]]
+		if a.N != b.N {
+			return a.N > b.N
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Evaluate fits every predictor on each fold's training split and scores it
+// on the test split.
+func Evaluate(folds []Fold, fitters []Fitter) ([]Report, error) {
+	reports := make([]Report, len(fitters))
+	for i, f := range fitters {
+		reports[i] = Report{Name: f.Name, Overall: &Metrics{}, ByFormation: map[string]*Metrics{}}
+	}
+	for _, fold := range folds {
+		for i, f := range fitters {
+			p, err := f.Fit(fold.Train)
+			if err != nil {
+				return nil, fmt.Errorf("%s, %s: %w", f.Name, fold.Name, err)
+			}
+			r := &reports[i]
+			for _, s := range fold.Test {
+				probs, err := p.Predict(s.Opp, s.Our)
+				if err != nil {
+					return nil, fmt.Errorf("%s, %s: %w", f.Name, fold.Name, err)
+				}
+				r.Overall.add(probs, s.Outcome)
+				if r.ByFormation[s.Our] == nil {
+					r.ByFormation[s.Our] = &Metrics{}
+				}
+				r.ByFormation[s.Our].add(probs, s.Outcome)
+			}
+		}
+	}
+	return reports, nil
+}
@@ -0,0 +1,57 @@
+package recommender
+
+import "testing"
+
+// games returns both perspectives of n games, as Samples builds them.
+func games(n int) []Sample {
+	samples := []Sample{}
+	for g := 1; g <= n; g++ {
+		samples = append(samples,
+			Sample{Our: "4-4-2", Opp: "4-3-3", Outcome: Win, GameID: int64(g), Season: "1718"},
+			Sample{Our: "4-3-3", Opp: "4-4-2", Outcome: Loss, GameID: int64(g), Season: "1718"})
+	}
+	return samples
+}
+
+// sides fails the test if a game has samples on both sides of a split.
+func sides(t *testing.T, name string, train, test []Sample) {
+	t.Helper()
+	inTrain := map[int64]bool{}
+	for _, s := range train {
+		inTrain[s.GameID] = true
+	}
+	for _, s := range test {
+		if inTrain[s.GameID] {
+			t.Errorf("%s: game %d is in both the training and the test set", name, s.GameID)
+		}
+	}
+}
+
+func TestHoldoutKeepsGamesTogether(t *testing.T) {
+	samples := games(50)
+	for _, frac := range []float64{0.1, 0.2, 0.5} {
+		train, test := Holdout(samples, frac, 7)
+		if len(train)+len(test) != len(samples) {
+			t.Fatalf("holdout %g: %d + %d samples, want %d", frac, len(train), len(test), len(samples))
+		}
+		if want := 2 * int(50*frac+0.5); len(test) != want {
+			t.Errorf("holdout %g: %d test samples, want %d", frac, len(test), want)
+		}
+		sides(t, "holdout", train, test)
+	}
+}
+
+func TestKFoldKeepsGamesTogether(t *testing.T) {
+	folds, err := KFold(games(23), 5, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tested := 0
+	for _, f := range folds {
+		sides(t, f.Name, f.Train, f.Test)
+		tested += len(f.Test)
+	}
+	if tested != 46 {
+		t.Errorf("folds test %d samples, want each of 46 once", tested)
+	}
+}
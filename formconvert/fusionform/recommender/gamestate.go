@@ -0,0 +1,242 @@
+package recommender
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"fusionform/formation"
+)
+
+// FullTime is the minute regulation time ends.
+const FullTime = 90
+
+// GameState is the situation in a live match, seen from our side.
+type GameState struct {
+	Minute  int
+	For     int // goals we have scored
+	Against int // goals the opponent has scored
+}
+
+func (s GameState) String() string {
+	return fmt.Sprintf("%d-%d at %d'", s.For, s.Against, s.Minute)
+}
+
+// Remaining is the share of the match still to play.
+func (s GameState) Remaining() float64 {
+	return math.Max(float64(FullTime-s.Minute), 0) / FullTime
+}
+
+const (
+	// goalPrior is how many pseudo-matches the role-based prior of a
+	// formation's scoring and conceding rates is worth.
+	goalPrior = 20
+	// roleWeight is the change in a rate per attacking or defensive unit a
+	// formation has above the average shape.
+	roleWeight = 0.08
+	// maxGoals bounds the goals per side summed over in the score
+	// distribution; more than ten in the rest of a match is negligible.
+	maxGoals = 10
+)
+
+// GoalModel predicts how many goals each side scores in a formation matchup.
+// The results only hold final scores, so the model works with rates: every
+// formation has an attack multiplier on the league scoring rate and a defence
+// multiplier on the rate it concedes, estimated from the matches it played.
+// Rarely seen formations are pulled towards a prior from their roles, so a
+// shape with more strikers and wide midfielders is expected to score more
+// and one with more defenders to concede less.
+type GoalModel struct {
+	Mean       float64 // goals per team per match
+	Formations []string
+
+	attack  map[string]float64
+	defence map[string]float64
+	// average attacking and defensive units over the training samples
+	attackUnits, defenceUnits float64
+}
+
+// FitGoals estimates a goal model from samples.
+func FitGoals(samples []Sample) (*GoalModel, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples")
+	}
+	type totals struct{ n, scored, conceded float64 }
+	byOur := map[string]*totals{}
+	g := &GoalModel{attack: map[string]float64{}, defence: map[string]float64{}}
+	goals := 0
+	for _, s := range samples {
+		t := byOur[s.Our]
+		if t == nil {
+			t = &totals{}
+			byOur[s.Our] = t
+		}
+		t.n++
+		t.scored += float64(s.GoalsFor)
+		t.conceded += float64(s.GoalsAgainst)
+		goals += s.GoalsFor
+
+		att, def, err := units(s.Our)
+		if err != nil {
+			return nil, err
+		}
+		g.attackUnits += att
+		g.defenceUnits += def
+	}
+	n := float64(len(samples))
+	g.Mean = float64(goals) / n
+	g.attackUnits /= n
+	g.defenceUnits /= n
+	if g.Mean == 0 {
+		return nil, fmt.Errorf("no goals in %d samples", len(samples))
+	}
+
+	for f, t := range byOur {
+		att, def := g.prior(f)
+		g.attack[f] = (t.scored + goalPrior*g.Mean*att) / ((t.n + goalPrior) * g.Mean)
+		g.defence[f] = (t.conceded + goalPrior*g.Mean*def) / ((t.n + goalPrior) * g.Mean)
+		g.Formations = append(g.Formations, f)
+	}
+	sort.Strings(g.Formations)
+	return g, nil
+}
+
+// units counts a formation's attacking units (strikers, and wide midfielders
+// at half weight) and defensive units (centre-backs, and full-backs at half
+// weight) from its roles.
+func units(f string) (attack, defence float64, err error) {
+	roles, err := formation.ParseDashed(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	c := formation.Counts(roles)
+	attack = float64(c[formation.Striker]) + 0.5*float64(c[formation.WideMidfielder])
+	defence = float64(c[formation.CenterBack]) + 0.5*float64(c[formation.FullBack])
+	return attack, defence, nil
+}
+
+// prior returns the role-based attack and defence multipliers of a
+// formation, or 1 and 1 when it cannot be parsed.
+func (g *GoalModel) prior(f string) (attack, defence float64) {
+	att, def, err := units(f)
+	if err != nil {
+		return 1, 1
+	}
+	clamp := func(x float64) float64 { return math.Min(math.Max(x, 0.5), 1.5) }
+	return clamp(1 + roleWeight*(att-g.attackUnits)), clamp(1 - roleWeight*(def-g.defenceUnits))
+}
+
+func (g *GoalModel) multipliers(f string) (attack, defence float64, err error) {
+	if att, ok := g.attack[f]; ok {
+		return att, g.defence[f], nil
+	}
+	if _, _, err := units(f); err != nil {
+		return 0, 0, err
+	}
+	attack, defence = g.prior(f)
+	return attack, defence, nil
+}
+
+// Rates returns the goals per full match we are expected to score and
+// concede playing our formation against the opponent's. Formations that were
+// never seen are rated by their roles alone.
+func (g *GoalModel) Rates(opp, our string) (scored, conceded float64, err error) {
+	ourAtt, ourDef, err := g.multipliers(our)
+	if err != nil {
+		return 0, 0, err
+	}
+	oppAtt, oppDef, err := g.multipliers(opp)
+	if err != nil {
+		return 0, 0, err
+	}
+	return g.Mean * ourAtt * oppDef, g.Mean * oppAtt * ourDef, nil
+}
+
+// Predict returns the final outcome probabilities from the kick-off, so a
+// GoalModel can be evaluated like any other Predictor.
+func (g *GoalModel) Predict(opp, our string) (Probabilities, error) {
+	return g.PredictFrom(opp, our, GameState{})
+}
+
+// PredictFrom returns the final outcome probabilities when we switch to our
+// formation in the given state and both sides keep their shapes to the end.
+// Goals in the remaining time are Poisson with the matchup's rates scaled to
+// the minutes left.
+func (g *GoalModel) PredictFrom(opp, our string, state GameState) (Probabilities, error) {
+	scored, conceded, err := g.Rates(opp, our)
+	if err != nil {
+		return Probabilities{}, err
+	}
+	left := state.Remaining()
+	return finalOutcome(state.For-state.Against, poisson(scored*left), poisson(conceded*left)), nil
+}
+
+// poisson returns P(k) for k = 0..maxGoals.
+func poisson(rate float64) []float64 {
+	p := make([]float64, maxGoals+1)
+	p[0] = math.Exp(-rate)
+	for k := 1; k <= maxGoals; k++ {
+		p[k] = p[k-1] * rate / float64(k)
+	}
+	return p
+}
+
+// finalOutcome combines the current goal difference with the distributions of
+// goals still to be scored by each side.
+func finalOutcome(diff int, scored, conceded []float64) Probabilities {
+	var p Probabilities
+	total := 0.0
+	for x, px := range scored {
+		for y, py := range conceded {
+			q := px * py
+			p[outcomeFor(diff+x, y)] += q
+			total += q
+		}
+	}
+	for o := range p {
+		p[o] /= total
+	}
+	return p
+}
+
+// StateRecommendation is a formation scored from a live game state.
+type StateRecommendation struct {
+	Recommendation
+	// Expected goals for and against over the rest of the match.
+	GoalsFor, GoalsAgainst float64
+}
+
+// RecommendFrom scores every candidate formation against the opponent's from
+// the given state and returns them best first by expected points. With no
+// candidates, every formation the model was fitted on is scored.
+func (g *GoalModel) RecommendFrom(opp string, state GameState, candidates []string) ([]StateRecommendation, error) {
+	if state.Minute < 0 || state.For < 0 || state.Against < 0 {
+		return nil, fmt.Errorf("invalid game state %s", state)
+	}
+	if candidates == nil {
+		candidates = g.Formations
+	}
+	left := state.Remaining()
+	recs := make([]StateRecommendation, 0, len(candidates))
+	for _, our := range candidates {
+		scored, conceded, err := g.Rates(opp, our)
+		if err != nil {
+			return nil, err
+		}
+		p := finalOutcome(state.For-state.Against, poisson(scored*left), poisson(conceded*left))
+		recs = append(recs, StateRecommendation{
+			Recommendation: Recommendation{Formation: our, Probabilities: p},
+			GoalsFor:       scored * left,
+			GoalsAgainst:   conceded * left,
+		})
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		return recs[i].Probabilities.ExpectedPoints() > recs[j].Probabilities.ExpectedPoints()
+	})
+	return recs, nil
+}
+
+// GoalFitter fits a goal model, for comparison with the other predictors.
+var GoalFitter = Fitter{Name: "goals", Fit: func(train []Sample) (Predictor, error) {
+	return FitGoals(train)
+}}
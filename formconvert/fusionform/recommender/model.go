@@ -0,0 +1,258 @@
+// Package recommender trains and runs the formation recommender natively in
+// Go, replacing the PyTorch/ONNX pipeline of train.py and app_py.py.
+//
+// The model sees the same input as formation_predictor.onnx: the opponent's
+// formation one-hot encoded, followed by our formation one-hot encoded, each
+// over the sorted class list. Instead of regressing the 0/1/2 outcome with
+// MSE it predicts the probability of a loss, draw and win, which gives
+// calibrated scores and lets recommendations rank by expected points.
+//
+// # Model file format
+//
+// Models are saved as a single JSON object:
+//
+//	{
+//	  "format":   "fusionform-recommender",
+//	  "version":  1,
+//	  "classes":  ["3-4-2-1", "4-2-3-1", ...],
+//	  "outcomes": ["loss", "draw", "win"],
+//	  "layers": [
+//	    {"weights": [[...], ...], "bias": [...], "activation": "relu"},
+//	    {"weights": [[...], ...], "bias": [...], "activation": "softmax"}
+//	  ],
+//	  "trained": {"samples": 1520, "epochs": 60, ...}
+//	}
+//
+// classes is the formation vocabulary; the input vector has 2*len(classes)
+// entries, opponent block first. Each layer computes
+// activation(weights·x + bias) where weights has one row per output unit and
+// one column per input. Hidden layers use "relu" (or "identity"); the last
+// layer is "softmax" over the outcomes in the order listed. A model without
+// hidden layers is plain multinomial logistic regression. Any program that
+// can read JSON can run the model with a few lines of matrix arithmetic.
+package recommender
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+const (
+	formatName    = "fusionform-recommender"
+	formatVersion = 1
+)
+
+// Layer is one fully connected layer.
+type Layer struct {
+	Weights    [][]float64 `json:"weights"` // [output][input]
+	Bias       []float64   `json:"bias"`
+	Activation string      `json:"activation"` // "relu", "identity" or "softmax"
+}
+
+// TrainingInfo records how a model was trained.
+type TrainingInfo struct {
+	Samples      int     `json:"samples"`
+	Epochs       int     `json:"epochs"`
+	Hidden       []int   `json:"hidden"`
+	LearningRate float64 `json:"learning_rate"`
+	L2           float64 `json:"l2"`
+	Seed         int64   `json:"seed"`
+	TrainLoss    float64 `json:"train_loss"`
+	Families     bool    `json:"families,omitempty"` // formations replaced by their family's canonical formation
+}
+
+// Model is a trained formation recommender.
+type Model struct {
+	Format   string       `json:"format"`
+	Version  int          `json:"version"`
+	Classes  []string     `json:"classes"`
+	Outcomes []string     `json:"outcomes"`
+	Layers   []Layer      `json:"layers"`
+	Trained  TrainingInfo `json:"trained"`
+
+	index map[string]int
+}
+
+// Probabilities are the predicted chances of each outcome, indexed by Outcome.
+type Probabilities [numOutcomes]float64
+
+// ExpectedPoints is 3·P(win) + P(draw).
+func (p Probabilities) ExpectedPoints() float64 {
+	return 3*p[Win] + p[Draw]
+}
+
+// Recommendation is a formation scored against a given opponent.
+type Recommendation struct {
+	Formation     string
+	Probabilities Probabilities
+}
+
+// Load reads a model saved by Save.
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Model{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Save writes the model as JSON (see the package documentation).
+func (m *Model) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (m *Model) validate() error {
+	if m.Format != formatName {
+		return fmt.Errorf("not a recommender model (format %q)", m.Format)
+	}
+	if m.Version != formatVersion {
+		return fmt.Errorf("unsupported model version %d", m.Version)
+	}
+	if len(m.Classes) == 0 || len(m.Layers) == 0 {
+		return fmt.Errorf("model has no classes or layers")
+	}
+	in := m.inputSize()
+	for i, l := range m.Layers {
+		if len(l.Weights) != len(l.Bias) {
+			return fmt.Errorf("layer %d: %d weight rows but %d biases", i, len(l.Weights), len(l.Bias))
+		}
+		for _, row := range l.Weights {
+			if len(row) != in {
+				return fmt.Errorf("layer %d: expected %d inputs, got %d", i, in, len(row))
+			}
+		}
+		switch l.Activation {
+		case "relu", "identity", "softmax":
+		default:
+			return fmt.Errorf("layer %d: unknown activation %q", i, l.Activation)
+		}
+		in = len(l.Bias)
+	}
+	if in != int(numOutcomes) || m.Layers[len(m.Layers)-1].Activation != "softmax" {
+		return fmt.Errorf("last layer must be a softmax over %d outcomes", numOutcomes)
+	}
+	m.buildIndex()
+	return nil
+}
+
+func (m *Model) buildIndex() {
+	m.index = make(map[string]int, len(m.Classes))
+	for i, c := range m.Classes {
+		m.index[c] = i
+	}
+}
+
+// inputSize is the width of the input vector: both one-hot blocks.
+func (m *Model) inputSize() int {
+	return 2 * len(m.Classes)
+}
+
+// Knows reports whether a formation is in the model's vocabulary.
+func (m *Model) Knows(formation string) bool {
+	_, ok := m.index[formation]
+	return ok
+}
+
+// encode builds the input vector [opponent one-hot, our one-hot].
+func (m *Model) encode(opp, our string) ([]float64, error) {
+	oppIdx, ok := m.index[opp]
+	if !ok {
+		return nil, fmt.Errorf("formation %q not recognised", opp)
+	}
+	ourIdx, ok := m.index[our]
+	if !ok {
+		return nil, fmt.Errorf("formation %q not recognised", our)
+	}
+	x := make([]float64, m.inputSize())
+	x[oppIdx] = 1
+	x[len(m.Classes)+ourIdx] = 1
+	return x, nil
+}
+
+// forward runs the network and returns every layer's activations, input
+// first, so training can reuse them for backpropagation.
+func (m *Model) forward(x []float64) [][]float64 {
+	acts := [][]float64{x}
+	for _, l := range m.Layers {
+		out := make([]float64, len(l.Bias))
+		for j, row := range l.Weights {
+			sum := l.Bias[j]
+			for i, w := range row {
+				if x[i] != 0 {
+					sum += w * x[i]
+				}
+			}
+			out[j] = sum
+		}
+		switch l.Activation {
+		case "relu":
+			for j := range out {
+				out[j] = math.Max(out[j], 0)
+			}
+		case "softmax":
+			softmax(out)
+		}
+		acts = append(acts, out)
+		x = out
+	}
+	return acts
+}
+
+// Predict returns the outcome probabilities for playing our formation against
+// the opponent's.
+func (m *Model) Predict(opp, our string) (Probabilities, error) {
+	x, err := m.encode(opp, our)
+	if err != nil {
+		return Probabilities{}, err
+	}
+	acts := m.forward(x)
+	var p Probabilities
+	copy(p[:], acts[len(acts)-1])
+	return p, nil
+}
+
+// Recommend scores every known formation against the opponent's and returns
+// them best first by expected points.
+func (m *Model) Recommend(opp string) ([]Recommendation, error) {
+	recs := make([]Recommendation, 0, len(m.Classes))
+	for _, our := range m.Classes {
+		p, err := m.Predict(opp, our)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, Recommendation{Formation: our, Probabilities: p})
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		return recs[i].Probabilities.ExpectedPoints() > recs[j].Probabilities.ExpectedPoints()
+	})
+	return recs, nil
+}
+
+func softmax(v []float64) {
+	maxV := math.Inf(-1)
+	for _, x := range v {
+		maxV = math.Max(maxV, x)
+	}
+	sum := 0.0
+	for i, x := range v {
+		v[i] = math.Exp(x - maxV)
+		sum += v[i]
+	}
+	for i := range v {
+		v[i] /= sum
+	}
+}
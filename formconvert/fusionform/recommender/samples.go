@@ -0,0 +1,98 @@
+package recommender
+
+import (
+	"sort"
+
+	"fusionform/dataset"
+)
+
+// Outcome of a match from one team's perspective, numbered as in train.py.
+type Outcome int
+
+const (
+	Loss Outcome = iota
+	Draw
+	Win
+	numOutcomes
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Loss:
+		return "loss"
+	case Draw:
+		return "draw"
+	case Win:
+		return "win"
+	}
+	return "unknown"
+}
+
+// Points is the league points the outcome earns.
+func (o Outcome) Points() int {
+	switch o {
+	case Win:
+		return 3
+	case Draw:
+		return 1
+	}
+	return 0
+}
+
+// outcomeFor returns the outcome for a side scoring for and conceding against.
+func outcomeFor(scored, conceded int) Outcome {
+	switch {
+	case scored > conceded:
+		return Win
+	case scored == conceded:
+		return Draw
+	}
+	return Loss
+}
+
+// Sample is one training row: the formation we played, the formation the
+// opponent played and how it went for us.
+type Sample struct {
+	Our          string
+	Opp          string
+	Outcome      Outcome
+	GoalsFor     int
+	GoalsAgainst int
+	Season       string
+	GameID       int64
+}
+
+// Samples builds the training rows from joined fixtures. Like train.py, each
+// played match contributes one row from the home side's perspective and one
+// from the away side's. Unplayed fixtures are skipped.
+func Samples(fixtures []dataset.Fixture) []Sample {
+	samples := make([]Sample, 0, 2*len(fixtures))
+	for _, f := range fixtures {
+		if !f.Match.Played {
+			continue
+		}
+		season := f.Home.Season
+		gh, ga := f.Match.GH, f.Match.GA
+		samples = append(samples,
+			Sample{Our: f.Home.Formation, Opp: f.Away.Formation, Outcome: outcomeFor(gh, ga), GoalsFor: gh, GoalsAgainst: ga, Season: season, GameID: f.Home.GameID},
+			Sample{Our: f.Away.Formation, Opp: f.Home.Formation, Outcome: outcomeFor(ga, gh), GoalsFor: ga, GoalsAgainst: gh, Season: season, GameID: f.Home.GameID},
+		)
+	}
+	return samples
+}
+
+// Classes returns the sorted set of formations appearing in samples, the same
+// ordering sklearn's LabelEncoder gives.
+func Classes(samples []Sample) []string {
+	seen := map[string]bool{}
+	for _, s := range samples {
+		seen[s.Our] = true
+		seen[s.Opp] = true
+	}
+	classes := make([]string, 0, len(seen))
+	for c := range seen {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	return classes
+}
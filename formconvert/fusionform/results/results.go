@@ -0,0 +1,192 @@
+// Package results loads match results from the games.parquet file that
+// train.py and data_preprocessing.py read (columns date, home, away, gh, ga).
+package results
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fusionform/parquet"
+	"fusionform/teams"
+)
+
+// DefaultPath is where the Python scripts expect the results file, relative
+// to the repository root.
+const DefaultPath = "data/results/games.parquet"
+
+// Match is one row of the results file.
+type Match struct {
+	Row    int   // zero-based row in the source file, for reporting
+	GameID int64 // 0 when the file has no gameid column
+	Date   time.Time
+	Home   string
+	Away   string
+	GH     int
+	GA     int
+	Played bool // false when either score is missing
+}
+
+// Season returns the season the match belongs to, e.g. "1718".
+func (m Match) Season() string {
+	return Season(m.Date)
+}
+
+// Season returns the season code used in the formations file names for a
+// date. Seasons run from July to June, so 2017-08-11 and 2018-05-13 are both
+// "1718".
+func Season(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	start := t.Year()
+	if t.Month() < time.July {
+		start--
+	}
+	return fmt.Sprintf("%02d%02d", start%100, (start+1)%100)
+}
+
+func (m Match) String() string {
+	score := "-"
+	if m.Played {
+		score = fmt.Sprintf("%d-%d", m.GH, m.GA)
+	}
+	return fmt.Sprintf("%s %s %s %s", m.Date.Format("2006-01-02"), m.Home, score, m.Away)
+}
+
+// Load reads every match from a results Parquet file, resolving team names
+// through names.
+func Load(path string, names *teams.Registry) ([]Match, error) {
+	f, err := parquet.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := FromParquet(f, names)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return matches, nil
+}
+
+// FromParquet converts the columns of an opened results file into matches,
+// with team names resolved through names. Missing team names or dates are
+// kept as zero values so that joins can report the row instead of dropping it.
+func FromParquet(f *parquet.File, names *teams.Registry) ([]Match, error) {
+	columns := map[string][]any{}
+	for _, name := range []string{"date", "home", "away", "gh", "ga"} {
+		values, err := f.ReadColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = values
+	}
+	if _, ok := f.Column("gameid"); ok {
+		values, err := f.ReadColumn("gameid")
+		if err != nil {
+			return nil, err
+		}
+		columns["gameid"] = values
+	}
+
+	n := len(columns["date"])
+	for name, values := range columns {
+		if len(values) != n {
+			return nil, fmt.Errorf("column %q has %d rows, expected %d", name, len(values), n)
+		}
+	}
+
+	matches := make([]Match, 0, n)
+	for i := 0; i < n; i++ {
+		m := Match{Row: i}
+		var err error
+		if m.Date, err = toTime(columns["date"][i]); err != nil {
+			return nil, fmt.Errorf("row %d: date: %w", i, err)
+		}
+		if home := toString(columns["home"][i]); home != "" {
+			m.Home = names.Canonical(home)
+		}
+		if away := toString(columns["away"][i]); away != "" {
+			m.Away = names.Canonical(away)
+		}
+
+		gh, okH, err := toInt(columns["gh"][i])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: gh: %w", i, err)
+		}
+		ga, okA, err := toInt(columns["ga"][i])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: ga: %w", i, err)
+		}
+		m.GH, m.GA, m.Played = int(gh), int(ga), okH && okA
+
+		if ids, ok := columns["gameid"]; ok {
+			id, _, err := toInt(ids[i])
+			if err != nil {
+				return nil, fmt.Errorf("row %d: gameid: %w", i, err)
+			}
+			m.GameID = id
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+var dateLayouts = []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339, "02/01/2006", "2006/01/02"}
+
+func toTime(v any) (time.Time, error) {
+	switch x := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return x, nil
+	case string:
+		s := strings.TrimSpace(x)
+		if s == "" {
+			return time.Time{}, nil
+		}
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognised date %q", s)
+	}
+	return time.Time{}, fmt.Errorf("unsupported value type %T", v)
+}
+
+func toString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case []byte:
+		return strings.TrimSpace(string(x))
+	}
+	return ""
+}
+
+// toInt reports false for nulls and NaN, which pandas writes for unplayed games.
+func toInt(v any) (int64, bool, error) {
+	switch x := v.(type) {
+	case nil:
+		return 0, false, nil
+	case int64:
+		return x, true, nil
+	case float64:
+		if math.IsNaN(x) {
+			return 0, false, nil
+		}
+		if x != math.Trunc(x) {
+			return 0, false, fmt.Errorf("non-integer value %v", x)
+		}
+		return int64(x), true, nil
+	case string:
+		if strings.TrimSpace(x) == "" {
+			return 0, false, nil
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64)
+		return n, err == nil, err
+	}
+	return 0, false, fmt.Errorf("unsupported value type %T", v)
+}
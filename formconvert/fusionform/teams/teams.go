@@ -0,0 +1,231 @@
+// Package teams resolves the many spellings of a club name ("Brighton",
+// "Brighton and Hove Albion", "Brighton (England)") to one canonical team.
+//
+// Every dataset loader and join takes a *Registry so results files and
+// formation files that spell clubs differently still line up. A nil
+// *Registry only applies the cleaning clean_team_name does in the Python
+// scripts.
+package teams
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed aliases.csv
+var defaultAliases string
+
+// Registry maps aliases to canonical team names.
+type Registry struct {
+	canonical map[string]string // normalised alias -> canonical name
+	teams     map[string]bool   // canonical names
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{canonical: map[string]string{}, teams: map[string]bool{}}
+}
+
+// Default returns a registry holding the built-in aliases for every club in
+// data/formations.
+func Default() *Registry {
+	r := NewRegistry()
+	if err := r.Read(strings.NewReader(defaultAliases)); err != nil {
+		panic("teams: invalid built-in aliases: " + err.Error())
+	}
+	return r
+}
+
+// Load returns the built-in registry extended with the aliases in a CSV file
+// (see Read for the format).
+func Load(path string) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := Default()
+	if err := r.Read(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Read adds aliases from CSV with a "team,alias" header. Each row maps alias
+// to the canonical team; a row with an empty alias just declares the team.
+// Lines starting with # are comments.
+func (r *Registry) Read(in io.Reader) error {
+	cr := csv.NewReader(in)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) < 2 || strings.TrimSpace(header[0]) != "team" || strings.TrimSpace(header[1]) != "alias" {
+		return fmt.Errorf("header must be \"team,alias\"")
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rec) < 1 || strings.TrimSpace(rec[0]) == "" {
+			return fmt.Errorf("line %d: missing team", line)
+		}
+		aliases := []string{}
+		if len(rec) > 1 && strings.TrimSpace(rec[1]) != "" {
+			aliases = append(aliases, rec[1])
+		}
+		if err := r.Add(rec[0], aliases...); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// Add registers a canonical team and its aliases. It fails if an alias
+// already belongs to a different team.
+func (r *Registry) Add(team string, aliases ...string) error {
+	team = strings.TrimSpace(team)
+	for _, name := range append([]string{team}, aliases...) {
+		key := normalize(name)
+		if key == "" {
+			return fmt.Errorf("empty alias for %q", team)
+		}
+		if existing, ok := r.canonical[key]; ok && existing != team {
+			return fmt.Errorf("alias %q already maps to %q", strings.TrimSpace(name), existing)
+		}
+		r.canonical[key] = team
+	}
+	r.teams[team] = true
+	return nil
+}
+
+// Resolve returns the canonical name for a spelling and whether it is known.
+func (r *Registry) Resolve(name string) (string, bool) {
+	if r == nil {
+		return Clean(name), false
+	}
+	team, ok := r.canonical[normalize(name)]
+	return team, ok
+}
+
+// Canonical returns the canonical name for a spelling, or the cleaned
+// spelling itself when it is unknown.
+func (r *Registry) Canonical(name string) string {
+	if team, ok := r.Resolve(name); ok {
+		return team
+	}
+	return Clean(name)
+}
+
+// Teams returns the canonical team names in alphabetical order.
+func (r *Registry) Teams() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.teams))
+	for t := range r.teams {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Suggest returns up to n canonical teams whose names or aliases are close
+// to an unknown spelling, best first.
+func (r *Registry) Suggest(name string, n int) []string {
+	if r == nil || n <= 0 {
+		return nil
+	}
+	key := normalize(name)
+	if key == "" {
+		return nil
+	}
+	best := map[string]int{}
+	for alias, team := range r.canonical {
+		d := levenshtein(key, alias)
+		if strings.HasPrefix(alias, key) || strings.HasPrefix(key, alias) {
+			d = min(d, 1) // "man" for "manchester city" is a strong hint
+		}
+		if d > max(2, len(key)/3) {
+			continue
+		}
+		if cur, ok := best[team]; !ok || d < cur {
+			best[team] = d
+		}
+	}
+	suggestions := make([]string, 0, len(best))
+	for team := range best {
+		suggestions = append(suggestions, team)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if best[a] != best[b] {
+			return best[a] < best[b]
+		}
+		return a < b
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+// Clean mirrors clean_team_name in the Python scripts: it drops a trailing
+// country qualifier such as " (England)" and surrounding whitespace.
+func Clean(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+		name = strings.TrimSpace(name[:i])
+	}
+	return name
+}
+
+// normalize folds a name to a lookup key: cleaned, lower case, "&" read as
+// "and", punctuation dropped and the FC/AFC club suffixes ignored.
+func normalize(name string) string {
+	name = strings.ToLower(Clean(name))
+	name = strings.ReplaceAll(name, "&", " and ")
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if w != "fc" && w != "afc" {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
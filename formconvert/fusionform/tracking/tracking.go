@@ -0,0 +1,286 @@
+// Package tracking reads player tracking exports (columns player, frame, x,
+// y) and infers the formation a team actually held: positions are averaged
+// per player over a phase of play, the outfield players are clustered into
+// lines by how far up the pitch they stood, and the lines give a formation
+// string and a role list for the converters.
+//
+// Tracking coordinates are in metres with x along the pitch, from the goal
+// the team defends towards the one it attacks, and y across it from the left
+// touchline. A team attacking the other way is read with Flip.
+package tracking
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fusionform/formation"
+)
+
+// MinLineGap is the smallest gap between two players' depths, as a share of
+// the pitch length, that starts a fourth or fifth line when the number of
+// lines is inferred.
+const MinLineGap = 0.08
+
+// MaxLines is the most lines Infer finds on its own.
+const MaxLines = 5
+
+// Sample is one player's position in one frame.
+type Sample struct {
+	Player string
+	Frame  int
+	X, Y   float64 // metres
+}
+
+// Load reads a tracking CSV file.
+func Load(path string) ([]Sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	samples, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
+
+// Read reads tracking samples from CSV with a header naming the player,
+// frame, x and y columns; other columns are ignored.
+func Read(in io.Reader) ([]Sample, error) {
+	r := csv.NewReader(in)
+	r.Comment = '#'
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"player", "frame", "x", "y"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("missing %q column", required)
+		}
+	}
+
+	samples := []Sample{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		s := Sample{Player: strings.TrimSpace(rec[col["player"]])}
+		if s.Frame, err = strconv.Atoi(strings.TrimSpace(rec[col["frame"]])); err != nil {
+			return nil, fmt.Errorf("line %d: invalid frame %q", line, rec[col["frame"]])
+		}
+		if s.X, err = strconv.ParseFloat(strings.TrimSpace(rec[col["x"]]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid x %q", line, rec[col["x"]])
+		}
+		if s.Y, err = strconv.ParseFloat(strings.TrimSpace(rec[col["y"]]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid y %q", line, rec[col["y"]])
+		}
+		samples = append(samples, s)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples")
+	}
+	return samples, nil
+}
+
+// ParsePitch reads a pitch size: "105x68" in metres (length by width), or
+// 11, 8 or 7 for the format's standard pitch.
+func ParsePitch(s string) (formation.Pitch, error) {
+	switch strings.TrimSpace(s) {
+	case "11":
+		return formation.Pitch11, nil
+	case "8":
+		return formation.Pitch8, nil
+	case "7":
+		return formation.Pitch7, nil
+	}
+	l, w, ok := strings.Cut(strings.ToLower(s), "x")
+	length, lerr := strconv.ParseFloat(strings.TrimSpace(l), 64)
+	width, werr := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	if !ok || lerr != nil || werr != nil || length <= 0 || width <= 0 {
+		return formation.Pitch{}, fmt.Errorf("invalid pitch %q (want LENGTHxWIDTH in metres, e.g. 105x68, or 11, 8 or 7)", s)
+	}
+	return formation.Pitch{Name: s, Length: length, Width: width}, nil
+}
+
+// Position is a player's average position over a phase, normalised to the
+// pitch (see formation.Point).
+type Position struct {
+	Player string
+	Pos    formation.Point
+	Frames int // samples averaged
+}
+
+// Phase is a window of frames and the players' average positions in it,
+// deepest player first.
+type Phase struct {
+	From, To  int // frames, inclusive
+	Positions []Position
+}
+
+// Phases splits the frames of the samples into n windows of equal length
+// and averages each player's position in every window, normalised to pitch.
+// Flip reads a team attacking towards x = 0. Windows without samples are
+// left out.
+func Phases(samples []Sample, n int, pitch formation.Pitch, flip bool) []Phase {
+	if n < 1 {
+		n = 1
+	}
+	first, last := samples[0].Frame, samples[0].Frame
+	for _, s := range samples {
+		first, last = min(first, s.Frame), max(last, s.Frame)
+	}
+	span := last - first + 1
+	phases := []Phase{}
+	for i := 0; i < n; i++ {
+		from, to := first+span*i/n, first+span*(i+1)/n-1
+		if to < from {
+			continue
+		}
+		type sum struct {
+			x, y float64
+			n    int
+		}
+		sums := map[string]*sum{}
+		order := []string{}
+		for _, s := range samples {
+			if s.Frame < from || s.Frame > to {
+				continue
+			}
+			if sums[s.Player] == nil {
+				sums[s.Player] = &sum{}
+				order = append(order, s.Player)
+			}
+			sums[s.Player].x += s.X
+			sums[s.Player].y += s.Y
+			sums[s.Player].n++
+		}
+		if len(order) == 0 {
+			continue
+		}
+		p := Phase{From: from, To: to}
+		for _, player := range order {
+			s := sums[player]
+			pt := formation.Point{X: s.y / float64(s.n) / pitch.Width, Y: s.x / float64(s.n) / pitch.Length}
+			if flip {
+				pt = formation.Point{X: 1 - pt.X, Y: 1 - pt.Y}
+			}
+			p.Positions = append(p.Positions, Position{Player: player, Pos: pt, Frames: s.n})
+		}
+		sort.SliceStable(p.Positions, func(a, b int) bool { return p.Positions[a].Pos.Y < p.Positions[b].Pos.Y })
+		phases = append(phases, p)
+	}
+	return phases
+}
+
+// Inference is the formation found in one phase.
+type Inference struct {
+	Keeper    string       // empty when no goalkeeper was tracked
+	Lines     [][]Position // back to front, each left to right
+	Formation string       // dashed, e.g. "4-2-3-1"
+	Roles     []formation.Role
+}
+
+// Shape is the inferred formation read as defence, midfield and attack, as
+// the converters take it: every line between the first and the last is
+// midfield.
+func (inf *Inference) Shape() formation.Shape {
+	return formation.ShapeOf(inf.Roles)
+}
+
+// Infer finds the formation of positions. The goalkeeper is the player named
+// keeper or, when keeper is empty and there is one player more than a side's
+// outfield (10, 7 or 6), the deepest player. The outfield players are sorted
+// by depth and split into lines at the largest gaps between them: lines of
+// them when lines > 0, otherwise three, plus one more for every further gap
+// of at least MinLineGap up to MaxLines.
+//
+// Each player becomes a role named after them, typed by their place in the
+// lines as in the datasets (see formation.LineRoles), and carrying their
+// average position.
+func Infer(positions []Position, keeper string, lines int) (*Inference, error) {
+	inf := &Inference{}
+	outfield := []Position{}
+	for _, p := range positions {
+		if keeper != "" && p.Player == keeper {
+			inf.Keeper = p.Player
+			continue
+		}
+		outfield = append(outfield, p)
+	}
+	if keeper != "" && inf.Keeper == "" {
+		return nil, fmt.Errorf("keeper %s was not tracked", keeper)
+	}
+	if keeper == "" {
+		switch len(outfield) {
+		case 11, 8, 7:
+			deepest := 0
+			for i, p := range outfield {
+				if p.Pos.Y < outfield[deepest].Pos.Y {
+					deepest = i
+				}
+			}
+			inf.Keeper = outfield[deepest].Player
+			outfield = append(outfield[:deepest:deepest], outfield[deepest+1:]...)
+		}
+	}
+	if lines > len(outfield) {
+		return nil, fmt.Errorf("cannot make %d lines from %d outfield players", lines, len(outfield))
+	}
+	if len(outfield) < 3 {
+		return nil, fmt.Errorf("need at least 3 outfield players, tracked %d", len(outfield))
+	}
+
+	sort.SliceStable(outfield, func(a, b int) bool { return outfield[a].Pos.Y < outfield[b].Pos.Y })
+	gaps := make([]int, len(outfield)-1) // gaps[k] separates players k and k+1
+	for k := range gaps {
+		gaps[k] = k
+	}
+	depthGap := func(k int) float64 { return outfield[k+1].Pos.Y - outfield[k].Pos.Y }
+	sort.SliceStable(gaps, func(a, b int) bool { return depthGap(gaps[a]) > depthGap(gaps[b]) })
+	if lines <= 0 {
+		lines = 3
+		for lines < MaxLines && lines-1 < len(gaps) && depthGap(gaps[lines-1]) >= MinLineGap {
+			lines++
+		}
+	}
+	splits := append([]int{}, gaps[:lines-1]...)
+	sort.Ints(splits)
+
+	start := 0
+	for _, k := range append(splits, len(outfield)-1) {
+		line := append([]Position{}, outfield[start:k+1]...)
+		sort.SliceStable(line, func(a, b int) bool { return line[a].Pos.X < line[b].Pos.X })
+		inf.Lines = append(inf.Lines, line)
+		start = k + 1
+	}
+
+	counts := make([]int, len(inf.Lines))
+	parts := make([]string, len(inf.Lines))
+	for i, line := range inf.Lines {
+		counts[i] = len(line)
+		parts[i] = strconv.Itoa(len(line))
+	}
+	inf.Formation = strings.Join(parts, "-")
+	for i, types := range formation.LineRoles(counts) {
+		for j, rt := range types {
+			p := inf.Lines[i][j]
+			inf.Roles = append(inf.Roles, formation.Role{RoleType: rt, Name: p.Player, Pos: p.Pos})
+		}
+	}
+	return inf, nil
+}
@@ -0,0 +1,289 @@
+// Package transitions mines the players column of the formation files for
+// real-world role flexibility. A shirt number that turns up in the back line
+// one week and in midfield the next shows which roles cover which, and the
+// counts can be exported as a compatibility table for the converters.
+//
+// A player is a team's shirt number within one season. Each appearance is
+// given a line (defence, midfield, attack) and a role from its position in
+// the line (see formation.LineRoles).
+package transitions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"fusionform/formation"
+	"fusionform/formations"
+)
+
+// Line is a band of the formation.
+type Line int
+
+const (
+	Defence Line = iota
+	Midfield
+	Attack
+	numLines
+)
+
+// Lines lists the lines back to front.
+var Lines = []Line{Defence, Midfield, Attack}
+
+func (l Line) String() string {
+	switch l {
+	case Defence:
+		return "DEF"
+	case Midfield:
+		return "MID"
+	case Attack:
+		return "ATT"
+	}
+	return "unknown"
+}
+
+// Player identifies a shirt number in one team's season.
+type Player struct {
+	Season string
+	Team   string
+	Number string
+}
+
+func (p Player) String() string {
+	return fmt.Sprintf("%s #%s (%s)", p.Team, p.Number, p.Season)
+}
+
+// Record is everything one player did across the lineups.
+type Record struct {
+	Player Player
+	Apps   int
+	Lines  [numLines]int
+	Roles  map[formation.RoleType]int
+}
+
+// Main returns the role the player filled most often, the earlier role in
+// formation.RoleTypes on a tie.
+func (r *Record) Main() formation.RoleType {
+	var best formation.RoleType
+	for _, rt := range formation.RoleTypes {
+		if r.Roles[rt] > r.Roles[best] {
+			best = rt
+		}
+	}
+	return best
+}
+
+// MainLine returns the line the player appeared in most often.
+func (r *Record) MainLine() Line {
+	best := Defence
+	for _, l := range Lines {
+		if r.Lines[l] > r.Lines[best] {
+			best = l
+		}
+	}
+	return best
+}
+
+// MultiLine reports whether the player appeared in more than one line.
+func (r *Record) MultiLine() bool {
+	n := 0
+	for _, apps := range r.Lines {
+		if apps > 0 {
+			n++
+		}
+	}
+	return n > 1
+}
+
+// Skipped is a lineup whose players could not be read.
+type Skipped struct {
+	Lineup formations.Lineup
+	Reason string
+}
+
+// Mine collects every player's appearances from the lineups. Lineups without
+// a players column, or whose players do not fit the formation, are skipped.
+func Mine(lineups []formations.Lineup) ([]*Record, []Skipped) {
+	byPlayer := map[Player]*Record{}
+	records := []*Record{}
+	skipped := []Skipped{}
+	for _, l := range lineups {
+		lines, err := playerLines(l)
+		if err != nil {
+			skipped = append(skipped, Skipped{Lineup: l, Reason: err.Error()})
+			continue
+		}
+		counts, _ := formation.ParseLines(l.Formation) // validated by playerLines
+		roles := formation.LineRoles(counts)
+		for i, numbers := range lines {
+			line := Midfield
+			switch i {
+			case 0:
+				line = Defence
+			case len(lines) - 1:
+				line = Attack
+			}
+			for j, number := range numbers {
+				p := Player{Season: l.Season, Team: l.Team, Number: number}
+				r := byPlayer[p]
+				if r == nil {
+					r = &Record{Player: p, Roles: map[formation.RoleType]int{}}
+					byPlayer[p] = r
+					records = append(records, r)
+				}
+				r.Apps++
+				r.Lines[line]++
+				r.Roles[roles[i][j]]++
+			}
+		}
+	}
+	return records, skipped
+}
+
+// playerLines splits a lineup's players into its outfield lines, dropping the
+// goalkeeper, and checks them against the formation.
+func playerLines(l formations.Lineup) ([][]string, error) {
+	if l.Players == "" {
+		return nil, fmt.Errorf("no players")
+	}
+	counts, err := formation.ParseLines(l.Formation)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(l.Players, ";")
+	if len(parts) != len(counts)+1 {
+		return nil, fmt.Errorf("players have %d lines, formation %s has %d plus the goalkeeper", len(parts), l.Formation, len(counts))
+	}
+	lines := make([][]string, len(counts))
+	for i, part := range parts[1:] {
+		lines[i] = strings.Fields(part)
+		if len(lines[i]) != counts[i] {
+			return nil, fmt.Errorf("line %d has %d players, formation %s says %d", i+1, len(lines[i]), l.Formation, counts[i])
+		}
+	}
+	return lines, nil
+}
+
+// Table is the transition counts between lines and between roles. Row a,
+// column b counts the appearances at b by players whose main line (or role)
+// is a.
+type Table struct {
+	Players   int // players with enough appearances
+	MultiLine int // of those, players seen in more than one line
+	Lines     [numLines][numLines]int
+	Roles     map[formation.RoleType]map[formation.RoleType]int
+}
+
+// Transitions builds the table from players with at least minApps
+// appearances.
+func Transitions(records []*Record, minApps int) *Table {
+	t := &Table{Roles: map[formation.RoleType]map[formation.RoleType]int{}}
+	for _, r := range records {
+		if r.Apps < minApps {
+			continue
+		}
+		t.Players++
+		if r.MultiLine() {
+			t.MultiLine++
+		}
+		main := r.MainLine()
+		for _, l := range Lines {
+			t.Lines[main][l] += r.Lines[l]
+		}
+		role := r.Main()
+		if t.Roles[role] == nil {
+			t.Roles[role] = map[formation.RoleType]int{}
+		}
+		for rt, apps := range r.Roles {
+			t.Roles[role][rt] += apps
+		}
+	}
+	return t
+}
+
+// LineFrequency is the share of appearances at line b by players whose main
+// line is a.
+func (t *Table) LineFrequency(a, b Line) float64 {
+	total := 0
+	for _, n := range t.Lines[a] {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(t.Lines[a][b]) / float64(total)
+}
+
+// RoleFrequency is the share of appearances at role b by players whose main
+// role is a.
+func (t *Table) RoleFrequency(a, b formation.RoleType) float64 {
+	total := 0
+	for _, n := range t.Roles[a] {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(t.Roles[a][b]) / float64(total)
+}
+
+// ObservedRoles returns the main roles in the table, in formation.RoleTypes
+// order.
+func (t *Table) ObservedRoles() []formation.RoleType {
+	roles := []formation.RoleType{}
+	for _, rt := range formation.RoleTypes {
+		if _, ok := t.Roles[rt]; ok {
+			roles = append(roles, rt)
+		}
+	}
+	return roles
+}
+
+// Compatibility turns the role transitions into a compatibility table. The
+// most frequent move between two different roles scores top, and every other
+// move scores in proportion to its frequency; moves scoring below min are
+// set to 0 so that, laid over the built-in matrix, the table decides every
+// pair among the observed roles. Roles always cover themselves.
+func (t *Table) Compatibility(top, min float64) formation.Compatibility {
+	roles := t.ObservedRoles()
+	maxFreq := 0.0
+	for _, a := range roles {
+		for _, b := range roles {
+			if a != b {
+				maxFreq = math.Max(maxFreq, t.RoleFrequency(a, b))
+			}
+		}
+	}
+	c := formation.Compatibility{}
+	for _, a := range roles {
+		for _, b := range roles {
+			if a == b {
+				continue
+			}
+			score := 0.0
+			if maxFreq > 0 {
+				score = math.Round(100*top*t.RoleFrequency(a, b)/maxFreq) / 100
+			}
+			if score < min {
+				score = 0
+			}
+			c.Set(a, b, score)
+		}
+	}
+	return c
+}
+
+// Flexible returns the players seen in more than one line with at least
+// minApps appearances, most appearances outside their main line first.
+func Flexible(records []*Record, minApps int) []*Record {
+	out := []*Record{}
+	for _, r := range records {
+		if r.Apps >= minApps && r.MultiLine() {
+			out = append(out, r)
+		}
+	}
+	away := func(r *Record) int { return r.Apps - r.Lines[r.MainLine()] }
+	sort.SliceStable(out, func(i, j int) bool { return away(out[i]) > away(out[j]) })
+	return out
+}